@@ -0,0 +1,481 @@
+package buffer
+
+import "encoding/binary"
+
+// byteStore is the byte level storage of Buffer and RingBuffer. Indexes are relative
+// to the read position and have been checked against ensureReadable, writes against
+// ensureWriteable.
+type byteStore interface {
+	Len() int
+	ensureReadable(size int) error
+	ensureWriteable(size int) error
+	peek(idx int, b []byte) []byte //len(b) bytes at idx, in place when possible
+	copyBytes(idx int, p []byte)
+	setBytes(idx int, p []byte)
+	write(p []byte)
+	skip(n int)
+}
+
+// accessor implements the typed Get, Read, Write and Set methods once for Buffer and
+// RingBuffer, which embed it with byteStore pointing back to themselves.
+type accessor struct {
+	byteStore
+	order binary.ByteOrder
+}
+
+//#region read logic
+
+func (t *accessor) GetBool(idx int) (bool, error) {
+	res, err := t.GetUInt8(idx)
+	return res != 0, err
+}
+
+func (t *accessor) GetUInt8(idx int) (uint8, error) {
+	if err := t.ensureReadable(idx + 1); err != nil {
+		return 0, err
+	}
+
+	return t.getUInt8(idx), nil
+}
+
+func (t *accessor) GetInt8(idx int) (int8, error) {
+	res, err := t.GetUInt8(idx)
+	return int8(res), err
+}
+
+func (t *accessor) GetByte(idx int) (byte, error) {
+	return t.GetUInt8(idx)
+}
+
+func (t *accessor) GetUInt16(idx int) (uint16, error) {
+	if err := t.ensureReadable(idx + 2); err != nil {
+		return 0, err
+	}
+
+	return t.getUInt16(idx), nil
+}
+
+func (t *accessor) GetInt16(idx int) (int16, error) {
+	res, err := t.GetUInt16(idx)
+	return int16(res), err
+}
+
+func (t *accessor) GetUInt32(idx int) (uint32, error) {
+	if err := t.ensureReadable(idx + 4); err != nil {
+		return 0, err
+	}
+
+	return t.getUInt32(idx), nil
+}
+
+func (t *accessor) GetInt32(idx int) (int32, error) {
+	res, err := t.GetUInt32(idx)
+	return int32(res), err
+}
+
+func (t *accessor) GetUInt64(idx int) (uint64, error) {
+	if err := t.ensureReadable(idx + 8); err != nil {
+		return 0, err
+	}
+
+	return t.getUInt64(idx), nil
+}
+
+func (t *accessor) GetInt64(idx int) (int64, error) {
+	res, err := t.GetUInt64(idx)
+	return int64(res), err
+}
+
+func (t *accessor) GetUInt(idx int) (uint, error) {
+	res, err := t.GetUInt64(idx)
+	return uint(res), err
+}
+
+func (t *accessor) GetInt(idx int) (int, error) {
+	res, err := t.GetInt64(idx)
+	return int(res), err
+}
+
+func (t *accessor) ReadBool() (bool, error) {
+	res, err := t.ReadUInt8()
+	return res != 0, err
+}
+
+func (t *accessor) ReadUInt8() (uint8, error) {
+	n, err := t.GetUInt8(0)
+	if err == nil {
+		t.skip(1)
+	}
+	return n, err
+}
+
+func (t *accessor) ReadInt8() (int8, error) {
+	res, err := t.ReadUInt8()
+	return int8(res), err
+}
+
+func (t *accessor) ReadByte() (byte, error) {
+	return t.ReadUInt8()
+}
+
+func (t *accessor) ReadUInt16() (uint16, error) {
+	n, err := t.GetUInt16(0)
+	if err == nil {
+		t.skip(2)
+	}
+	return n, err
+}
+
+func (t *accessor) ReadInt16() (int16, error) {
+	res, err := t.ReadUInt16()
+	return int16(res), err
+}
+
+func (t *accessor) ReadUInt32() (uint32, error) {
+	n, err := t.GetUInt32(0)
+	if err == nil {
+		t.skip(4)
+	}
+	return n, err
+}
+
+func (t *accessor) ReadInt32() (int32, error) {
+	res, err := t.ReadUInt32()
+	return int32(res), err
+}
+
+func (t *accessor) ReadUInt64() (uint64, error) {
+	n, err := t.GetUInt64(0)
+	if err == nil {
+		t.skip(8)
+	}
+	return n, err
+}
+
+func (t *accessor) ReadInt64() (int64, error) {
+	res, err := t.ReadUInt64()
+	return int64(res), err
+}
+
+func (t *accessor) ReadUInt() (uint, error) {
+	res, err := t.ReadUInt64()
+	return uint(res), err
+}
+
+func (t *accessor) ReadInt() (int, error) {
+	res, err := t.ReadInt64()
+	return int(res), err
+}
+
+func (t *accessor) GetBytes(idx int, size int) ([]byte, error) {
+	if err := t.ensureReadable(idx); err != nil {
+		return nil, err
+	}
+	if err := t.ensureReadable(idx + size); err != nil {
+		return nil, err
+	}
+
+	return t.getBytes(idx, size), nil
+}
+
+func (t *accessor) ReadBytes(size int) ([]byte, error) {
+	data, err := t.GetBytes(0, size)
+	if err == nil {
+		t.skip(size)
+	}
+
+	return data, err
+}
+
+func (t *accessor) ReadString(n int) (string, error) {
+	data, err := t.ReadBytes(n)
+	if err != nil {
+		return "", err
+	}
+
+	return bytesToString(data), nil
+}
+
+//#endregion
+
+//#region write logic
+
+func (t *accessor) WriteBytes(b []byte) error {
+	if err := t.ensureWriteable(len(b)); err != nil {
+		return err
+	}
+
+	t.write(b)
+	return nil
+}
+
+func (t *accessor) WriteBool(b bool) error {
+	var num byte = 0
+	if b {
+		num = 1
+	}
+	return t.WriteByte(num)
+}
+
+func (t *accessor) WriteByte(n byte) error {
+	return t.WriteUInt8(n)
+}
+
+func (t *accessor) WriteUInt8(n uint8) error {
+	if err := t.ensureWriteable(1); err != nil {
+		return err
+	}
+
+	t.writeUInt8(n)
+	return nil
+}
+
+func (t *accessor) WriteInt8(n int8) error {
+	return t.WriteUInt8(uint8(n))
+}
+
+func (t *accessor) WriteUInt16(n uint16) error {
+	if err := t.ensureWriteable(2); err != nil {
+		return err
+	}
+
+	t.writeUInt16(n)
+	return nil
+}
+
+func (t *accessor) WriteInt16(n int16) error {
+	return t.WriteUInt16(uint16(n))
+}
+
+func (t *accessor) WriteUInt32(n uint32) error {
+	if err := t.ensureWriteable(4); err != nil {
+		return err
+	}
+
+	t.writeUInt32(n)
+	return nil
+}
+
+func (t *accessor) WriteInt32(n int32) error {
+	return t.WriteUInt32(uint32(n))
+}
+
+func (t *accessor) WriteUInt64(n uint64) error {
+	if err := t.ensureWriteable(8); err != nil {
+		return err
+	}
+
+	t.writeUInt64(n)
+	return nil
+}
+
+func (t *accessor) WriteInt64(n int64) error {
+	return t.WriteUInt64(uint64(n))
+}
+
+func (t *accessor) WriteInt(n int) error {
+	return t.WriteInt64(int64(n))
+}
+
+func (t *accessor) WriteUInt(n uint) error {
+	return t.WriteUInt64(uint64(n))
+}
+
+func (t *accessor) WriteString(s string) error {
+	data := stringToBytes(s)
+	return t.WriteBytes(data)
+}
+
+func (t *accessor) Write(p []byte) (int, error) {
+	if err := t.WriteBytes(p); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+//#endregion
+
+//#region set logic
+
+func (t *accessor) SetBool(idx int, b bool) error {
+	var num byte = 0
+	if b {
+		num = 1
+	}
+	return t.SetByte(idx, num)
+}
+
+func (t *accessor) SetByte(idx int, n byte) error {
+	return t.SetUInt8(idx, n)
+}
+
+func (t *accessor) SetUInt8(idx int, n uint8) error {
+	if err := t.ensureReadable(idx + 1); err != nil {
+		return err
+	}
+
+	t.setBytes(idx, []byte{n})
+	return nil
+}
+
+func (t *accessor) SetInt8(idx int, n int8) error {
+	return t.SetUInt8(idx, uint8(n))
+}
+
+func (t *accessor) SetUInt16(idx int, n uint16) error {
+	if err := t.ensureReadable(idx + 2); err != nil {
+		return err
+	}
+
+	t.setUInt16Order(idx, n, t.order)
+	return nil
+}
+
+func (t *accessor) SetInt16(idx int, n int16) error {
+	return t.SetUInt16(idx, uint16(n))
+}
+
+func (t *accessor) SetUInt32(idx int, n uint32) error {
+	if err := t.ensureReadable(idx + 4); err != nil {
+		return err
+	}
+
+	t.setUInt32Order(idx, n, t.order)
+	return nil
+}
+
+func (t *accessor) SetInt32(idx int, n int32) error {
+	return t.SetUInt32(idx, uint32(n))
+}
+
+func (t *accessor) SetUInt64(idx int, n uint64) error {
+	if err := t.ensureReadable(idx + 8); err != nil {
+		return err
+	}
+
+	t.setUInt64Order(idx, n, t.order)
+	return nil
+}
+
+func (t *accessor) SetInt64(idx int, n int64) error {
+	return t.SetUInt64(idx, uint64(n))
+}
+
+func (t *accessor) SetBytes(idx int, p []byte) error {
+	if err := t.ensureReadable(idx); err != nil {
+		return err
+	}
+	if err := t.ensureReadable(idx + len(p)); err != nil {
+		return err
+	}
+
+	t.setBytes(idx, p)
+	return nil
+}
+
+//#endregion
+
+//#region common logic
+
+func (t *accessor) Skip(n int) error {
+	if err := t.ensureReadable(n); err != nil {
+		return err
+	}
+
+	t.skip(n)
+	return nil
+}
+
+//#endregion
+
+func (t *accessor) writeUInt8(n uint8) {
+	t.write([]byte{n})
+}
+
+func (t *accessor) writeUInt16(n uint16) {
+	t.writeUInt16Order(n, t.order)
+}
+
+func (t *accessor) writeUInt32(n uint32) {
+	t.writeUInt32Order(n, t.order)
+}
+
+func (t *accessor) writeUInt64(n uint64) {
+	t.writeUInt64Order(n, t.order)
+}
+
+func (t *accessor) writeUInt16Order(n uint16, order binary.ByteOrder) {
+	var b [2]byte
+	order.PutUint16(b[:], n)
+	t.write(b[:])
+}
+
+func (t *accessor) writeUInt32Order(n uint32, order binary.ByteOrder) {
+	var b [4]byte
+	order.PutUint32(b[:], n)
+	t.write(b[:])
+}
+
+func (t *accessor) writeUInt64Order(n uint64, order binary.ByteOrder) {
+	var b [8]byte
+	order.PutUint64(b[:], n)
+	t.write(b[:])
+}
+
+func (t *accessor) getUInt8(idx int) uint8 {
+	var b [1]byte
+	return t.peek(idx, b[:])[0]
+}
+
+func (t *accessor) getUInt16(idx int) uint16 {
+	return t.getUInt16Order(idx, t.order)
+}
+
+func (t *accessor) getUInt32(idx int) uint32 {
+	return t.getUInt32Order(idx, t.order)
+}
+
+func (t *accessor) getUInt64(idx int) uint64 {
+	return t.getUInt64Order(idx, t.order)
+}
+
+func (t *accessor) getUInt16Order(idx int, order binary.ByteOrder) uint16 {
+	var b [2]byte
+	return order.Uint16(t.peek(idx, b[:]))
+}
+
+func (t *accessor) getUInt32Order(idx int, order binary.ByteOrder) uint32 {
+	var b [4]byte
+	return order.Uint32(t.peek(idx, b[:]))
+}
+
+func (t *accessor) getUInt64Order(idx int, order binary.ByteOrder) uint64 {
+	var b [8]byte
+	return order.Uint64(t.peek(idx, b[:]))
+}
+
+func (t *accessor) getBytes(idx int, size int) []byte {
+	res := make([]byte, size)
+	t.copyBytes(idx, res)
+
+	return res
+}
+
+func (t *accessor) setUInt16Order(idx int, n uint16, order binary.ByteOrder) {
+	var b [2]byte
+	order.PutUint16(b[:], n)
+	t.setBytes(idx, b[:])
+}
+
+func (t *accessor) setUInt32Order(idx int, n uint32, order binary.ByteOrder) {
+	var b [4]byte
+	order.PutUint32(b[:], n)
+	t.setBytes(idx, b[:])
+}
+
+func (t *accessor) setUInt64Order(idx int, n uint64, order binary.ByteOrder) {
+	var b [8]byte
+	order.PutUint64(b[:], n)
+	t.setBytes(idx, b[:])
+}
@@ -1,254 +1,271 @@
 package buffer
 
 import (
+	"encoding/binary"
 	"errors"
 	"golang.org/x/sys/unix"
+	"io"
+	"net"
 	"syscall"
+	"unicode/utf8"
 )
 
 var (
 	ErrExceedMaximumSize = errors.New("exceed maximum size")
 	ErrNoEnoughData      = errors.New("no enough data to read")
+	ErrVarintOverflow    = errors.New("varint overflows a 64-bit integer")
+	ErrInvalidUnreadByte = errors.New("invalid use of UnreadByte")
+	ErrInvalidUnreadRune = errors.New("invalid use of UnreadRune")
+	ErrLineTooLong       = errors.New("line too long")
+	ErrNoMark            = errors.New("no mark is held")
+	ErrConsumed          = errors.New("region has already been consumed")
+	ErrMarked            = errors.New("cannot prepend while a mark is held")
 	defaultMinAllocSize  = 2048
 )
 
+// maxIovecs is IOV_MAX, the maximum number of iovecs accepted by a single readv/writev.
+const maxIovecs = 1024
+
 type Buffer struct {
-	nodes        []*node
+	accessor
+	nodes        []*node //ring of nc nodes starting at head, its length is a power of two
+	head         int
 	nc           int //node count
 	size         int
 	maxSize      int
 	minAllocSize int
+	maxLineSize  int
+	headroom     int
+	iovs         [][]byte          //reused by readv/writev
+	last         [utf8.UTFMax]byte //bytes consumed by the last ReadByte or ReadRune
+	lastSize     int
+	lastRune     bool
+	marked       bool
+	mark         int   //bytes consumed since Mark
+	off          int64 //stream offset of the read position
+	consumed     int64 //highest stream offset read so far, lowered only by ResetToMark
+	edits        int   //number of Insert and Delete calls which moved data
+	retainSize   int
+	spare        []*node //drained nodes retained for reuse
+	spareSize    int     //capacity of the spare nodes
 }
 
 //#region read logic
 
-func (t *Buffer) GetBool(idx int) (bool, error) {
-	res, err := t.GetUInt8(idx)
-	return res != 0, err
-}
-
-func (t *Buffer) GetUInt8(idx int) (uint8, error) {
-	if err := t.ensureReadable(1); err != nil {
-		return 0, err
+func (t *Buffer) ReadByte() (byte, error) {
+	b, err := t.ReadUInt8()
+	if err == nil {
+		t.last[0] = b
+		t.lastSize = 1
+		t.lastRune = false
 	}
-
-	return t.getUInt8(idx), nil
-}
-
-func (t *Buffer) GetInt8(idx int) (int8, error) {
-	res, err := t.GetUInt8(idx)
-	return int8(res), err
-}
-
-func (t *Buffer) GetByte(idx int) (byte, error) {
-	return t.GetUInt8(idx)
+	return b, err
 }
 
-func (t *Buffer) GetUInt16(idx int) (uint16, error) {
-	if err := t.ensureReadable(2); err != nil {
-		return 0, err
+// UnreadByte puts back the last byte consumed by ReadByte or ReadRune. Any other read
+// in between makes it fail with ErrInvalidUnreadByte. Like writes, it fails with
+// ErrExceedMaximumSize when the byte would not fit in MaxSize anymore.
+func (t *Buffer) UnreadByte() error {
+	if t.lastSize == 0 {
+		return ErrInvalidUnreadByte
 	}
 
-	return t.getUInt16(idx), nil
-}
-
-func (t *Buffer) GetInt16(idx int) (int16, error) {
-	res, err := t.GetUInt16(idx)
-	return int16(res), err
-}
-
-func (t *Buffer) GetUInt32(idx int) (uint32, error) {
-	if err := t.ensureReadable(4); err != nil {
-		return 0, err
+	if err := t.unread(t.last[t.lastSize-1 : t.lastSize]); err != nil {
+		return err
 	}
-
-	return t.getUInt32(idx), nil
-}
-
-func (t *Buffer) GetInt32(idx int) (int32, error) {
-	res, err := t.GetUInt32(idx)
-	return int32(res), err
+	t.lastSize = 0
+	return nil
 }
 
-func (t *Buffer) GetUInt64(idx int) (uint64, error) {
-	if err := t.ensureReadable(8); err != nil {
-		return 0, err
+func (t *Buffer) FindByte(ind int, b byte) (int, bool, error) {
+	if err := t.ensureReadable(ind); err != nil {
+		return -1, false, err
 	}
 
-	return t.getUInt64(idx), nil
-}
-
-func (t *Buffer) GetInt64(idx int) (int64, error) {
-	res, err := t.GetUInt64(idx)
-	return int64(res), err
+	if i := t.indexByte(ind, b); i >= 0 {
+		return i, true, nil
+	}
+	return -1, false, nil
 }
 
-func (t *Buffer) GetUInt(idx int) (uint, error) {
-	res, err := t.GetUInt64(idx)
-	return uint(res), err
-}
+// ReadLine reads a line terminated by "\n" or "\r\n" and returns it without the line
+// ending. Nothing is consumed until a whole line is available. Lines longer than
+// Options.MaxLineSize are rejected with ErrLineTooLong and left in the buffer.
+func (t *Buffer) ReadLine() ([]byte, error) {
+	ind, ok, _ := t.FindByte(0, '\n')
+	if !ok {
+		l := t.size
+		if l > 0 && t.getUInt8(l-1) == '\r' {
+			l-- //may be the start of "\r\n"
+		}
+		if t.maxLineSize > 0 && l > t.maxLineSize {
+			return nil, ErrLineTooLong
+		}
+		return nil, ErrNoEnoughData
+	}
 
-func (t *Buffer) GetInt(idx int) (int, error) {
-	res, err := t.GetInt64(idx)
-	return int(res), err
-}
+	l := ind
+	if l > 0 && t.getUInt8(l-1) == '\r' {
+		l--
+	}
+	if t.maxLineSize > 0 && l > t.maxLineSize {
+		return nil, ErrLineTooLong
+	}
 
-func (t *Buffer) ReadBool() (bool, error) {
-	res, err := t.ReadUInt8()
-	return res != 0, err
+	line := t.getBytes(0, l)
+	t.skip(ind + 1)
+	return line, nil
 }
 
-func (t *Buffer) ReadUInt8() (uint8, error) {
-	n, err := t.GetUInt8(0)
-	if err == nil {
-		t.skip(1)
+// ReadUntil reads up to and including the first delim. Nothing is consumed when delim
+// has not arrived yet.
+func (t *Buffer) ReadUntil(delim byte) ([]byte, error) {
+	ind, ok, _ := t.FindByte(0, delim)
+	if !ok {
+		return nil, ErrNoEnoughData
 	}
-	return n, err
-}
 
-func (t *Buffer) ReadInt8() (int8, error) {
-	res, err := t.ReadUInt8()
-	return int8(res), err
+	return t.ReadBytes(ind + 1)
 }
 
-func (t *Buffer) ReadByte() (byte, error) {
-	return t.ReadUInt8()
-}
-
-func (t *Buffer) ReadUInt16() (uint16, error) {
-	n, err := t.GetUInt16(0)
-	if err == nil {
-		t.skip(2)
+// ReadUntilBytes reads up to and including the first occurrence of delim, which may
+// span nodes. Nothing is consumed when delim has not arrived yet.
+func (t *Buffer) ReadUntilBytes(delim []byte) ([]byte, error) {
+	ind := t.Index(delim, 0)
+	if ind < 0 {
+		return nil, ErrNoEnoughData
 	}
-	return n, err
-}
 
-func (t *Buffer) ReadInt16() (int16, error) {
-	res, err := t.ReadUInt16()
-	return int16(res), err
+	return t.ReadBytes(ind + len(delim))
 }
 
-func (t *Buffer) ReadUInt32() (uint32, error) {
-	n, err := t.GetUInt32(0)
-	if err == nil {
-		t.skip(4)
+// ReadRune decodes an UTF-8 encoded rune, which may be split across nodes. Invalid
+// encodings consume one byte and return utf8.RuneError. An incomplete encoding
+// returns ErrNoEnoughData, while an empty buffer returns io.EOF like other io.RuneReaders.
+func (t *Buffer) ReadRune() (r rune, size int, err error) {
+	if t.size == 0 {
+		return 0, 0, io.EOF
 	}
-	return n, err
-}
-
-func (t *Buffer) ReadInt32() (int32, error) {
-	res, err := t.ReadUInt32()
-	return int32(res), err
-}
 
-func (t *Buffer) ReadUInt64() (uint64, error) {
-	n, err := t.GetUInt64(0)
-	if err == nil {
-		t.skip(8)
+	var b [utf8.UTFMax]byte
+	l := len(b)
+	if l > t.size {
+		l = t.size
 	}
-	return n, err
-}
+	p := t.peek(0, b[:l])
 
-func (t *Buffer) ReadInt64() (int64, error) {
-	res, err := t.ReadUInt64()
-	return int64(res), err
-}
+	if p[0] < utf8.RuneSelf {
+		r, size = rune(p[0]), 1
+	} else if !utf8.FullRune(p) {
+		return 0, 0, ErrNoEnoughData
+	} else {
+		r, size = utf8.DecodeRune(p)
+	}
 
-func (t *Buffer) ReadUInt() (uint, error) {
-	res, err := t.ReadUInt64()
-	return uint(res), err
-}
+	copy(t.last[:], p[:size])
+	t.skip(size)
+	t.lastSize = size
+	t.lastRune = true
 
-func (t *Buffer) ReadInt() (int, error) {
-	res, err := t.ReadInt64()
-	return int(res), err
+	return r, size, nil
 }
 
-func (t *Buffer) FindByte(ind int, b byte) (int, bool, error) {
-	if err := t.ensureReadable(ind); err != nil {
-		return -1, false, err
+// UnreadRune puts back the rune consumed by the last ReadRune.
+func (t *Buffer) UnreadRune() error {
+	if t.lastSize == 0 || !t.lastRune {
+		return ErrInvalidUnreadRune
 	}
 
-	i, ni := t.getNode(ind)
-	for ind < t.size {
-		if ni < t.nodes[i].w {
-			if t.nodes[i].buf[ni] == b {
-				return ind, true, nil
-			}
-
-			ni++
-			ind++
-		} else {
-			i++
-			ni = 0
-		}
+	if err := t.unread(t.last[:t.lastSize]); err != nil {
+		return err
 	}
-
-	return -1, false, nil
+	t.lastSize = 0
+	return nil
 }
 
-func (t *Buffer) GetBytes(idx int, size int) ([]byte, error) {
-	if err := t.ensureReadable(idx); err != nil {
-		return nil, err
+func (t *Buffer) Read(p []byte) (n int, err error) {
+	if len(p) == 0 {
+		return 0, nil
 	}
-	if err := t.ensureReadable(idx + size); err != nil {
-		return nil, err
+	if t.size == 0 {
+		return 0, io.EOF
 	}
 
-	return t.getBytes(idx, size), nil
-}
-
-func (t *Buffer) ReadBytes(size int) ([]byte, error) {
-	data, err := t.GetBytes(0, size)
-	if err == nil {
-		t.skip(size)
+	n = len(p)
+	if n > t.size {
+		n = t.size
 	}
+	t.copyBytes(0, p[:n])
+	t.skip(n)
 
-	return data, err
+	return n, nil
 }
 
-func (t *Buffer) ReadString(n int) (string, error) {
-	data, err := t.ReadBytes(n)
-	if err != nil {
-		return "", err
-	}
-
-	return bytesToString(data), nil
-}
+// WriteTo writes node memory to w directly. When w is a net.Conn the nodes are
+// handed over as net.Buffers so they can go out in a single writev.
+func (t *Buffer) WriteTo(w io.Writer) (n int64, err error) {
+	if _, ok := w.(net.Conn); ok {
+		for i := 0; i < t.nc; i++ {
+			if no := t.nodeAt(i); no.ReadableBytes() > 0 {
+				t.iovs = append(t.iovs, no.buf[no.r:no.w])
+			}
+		}
 
-func (t *Buffer) Read(p []byte) (n int, err error) {
-	n = 0
-	i := 0
+		bufs := net.Buffers(t.iovs)
+		n, err = bufs.WriteTo(w)
+		t.clearIovs()
+		t.skip(int(n))
+		return
+	}
 
-	for t.size > 0 && n < len(p) {
-		cn := copy(p[n:], t.nodes[0].buf[t.nodes[0].r:t.nodes[0].w])
+	for i := 0; i < t.nc; i++ {
+		no := t.nodeAt(i)
+		if no.ReadableBytes() == 0 {
+			continue
+		}
 
-		n += cn
-		t.nodes[0].r += cn
-		t.size -= cn
-		i++
+		n0, e0 := w.Write(no.buf[no.r:no.w])
+		n += int64(n0)
+		if e0 != nil {
+			err = e0
+			break
+		}
+		if n0 < no.ReadableBytes() {
+			err = io.ErrShortWrite
+			break
+		}
 	}
+	t.skip(int(n))
 
-	t.shrink()
-	t.adjust()
-
-	return n, nil
+	return
 }
 
 func (t *Buffer) ReadToFd(fd int) (n int, err error) {
 	ind := 0
 
 	for ind < t.nc {
-		no := t.nodes[ind]
-		n0, e0 := unix.Write(fd, no.buf[no.r:no.w])
+		total := 0
+		for ind < t.nc && len(t.iovs) < maxIovecs {
+			no := t.nodeAt(ind)
+			if no.ReadableBytes() > 0 {
+				t.iovs = append(t.iovs, no.buf[no.r:no.w])
+				total += no.ReadableBytes()
+			}
+			ind++
+		}
+		if total == 0 {
+			break
+		}
+
+		n0, e0 := unix.Writev(fd, t.iovs)
+		t.clearIovs()
 		if n0 > 0 {
 			n += n0
 		}
-		if e0 != nil || n0 < no.ReadableBytes() {
+		if e0 != nil || n0 < total {
 			err = e0
 			break
 		}
-		ind++
 	}
 
 	t.skip(n)
@@ -260,141 +277,200 @@ func (t *Buffer) ReadToFd(fd int) (n int, err error) {
 
 //#region write logic
 
-func (t *Buffer) WriteBytes(b []byte) error {
-	if err := t.ensureWriteable(len(b)); err != nil {
-		return err
+// Reserve returns n bytes of free space at the write position, allocating a node if
+// the tail node has not enough room. The slice may be appended to up to its capacity.
+// Nothing is written until Commit.
+func (t *Buffer) Reserve(n int) ([]byte, error) {
+	if n < 0 {
+		panic("invalid argument")
+	}
+	if err := t.ensureWriteable(n); err != nil {
+		return nil, err
 	}
 
-	w := t.writer()
-	if w != nil && w.WritableBytes() > 0 {
-		n := copy(w.buf[w.w:], b)
-		w.w += n
-		t.size += n
-
-		if n == len(b) {
-			return nil
+	if t.writer() == nil || t.writer().WritableBytes() < n || t.writer().WritableBytes() == 0 {
+		size := n
+		if size < t.minAllocSize {
+			size = t.minAllocSize
 		}
-		b = b[n:]
-	}
 
-	s := len(b)
-	if s < 2048 {
-		s = 2048
+		t.addNode(t.allocNode(size))
 	}
-	node := newNode(s)
-	node.w += copy(node.buf, b)
-	t.addNode(node)
-	t.size += node.w
-	return nil
-}
 
-func (t *Buffer) WriteBool(b bool) error {
-	var num byte = 0
-	if b {
-		num = 1
+	tail := t.writer()
+	end := tail.Cap()
+	if t.maxSize > 0 && t.maxSize-t.size < (end-tail.w) {
+		end = tail.w + t.maxSize - t.size
 	}
-	return t.WriteByte(num)
-}
 
-func (t *Buffer) WriteByte(n byte) error {
-	return t.WriteUInt8(n)
+	return tail.buf[tail.w : tail.w+n : end], nil
 }
 
-func (t *Buffer) WriteUInt8(n uint8) error {
-	if err := t.ensureWriteable(1); err != nil {
+// Commit appends n bytes written into the space returned by the last Reserve.
+func (t *Buffer) Commit(n int) error {
+	if n < 0 || n > 0 && (t.writer() == nil || t.writer().WritableBytes() < n) {
+		panic("invalid argument")
+	}
+	if err := t.ensureWriteable(n); err != nil {
 		return err
 	}
 
-	t.writeUInt8(n)
+	if n > 0 {
+		t.writer().w += n
+		t.size += n
+	}
 	return nil
 }
 
-func (t *Buffer) WriteInt8(n int8) error {
-	return t.WriteUInt8(uint8(n))
-}
-
-func (t *Buffer) WriteUInt16(n uint16) error {
-	if err := t.ensureWriteable(2); err != nil {
+// Prepend writes p in front of the read position, into the headroom of the head node
+// when possible. It cannot be used while a mark is held.
+func (t *Buffer) Prepend(p []byte) error {
+	if err := t.ensureWriteable(len(p)); err != nil {
 		return err
 	}
+	if t.marked {
+		return ErrMarked
+	}
+	if len(p) == 0 {
+		return nil
+	}
 
-	t.writeUInt16(n)
+	t.prepend(p)
+	t.lastSize = 0
 	return nil
 }
 
-func (t *Buffer) WriteInt16(n int16) error {
-	return t.WriteUInt16(uint16(n))
+func (t *Buffer) PrependUInt16(n uint16) error {
+	return t.prependUInt16Order(n, t.order)
 }
 
-func (t *Buffer) WriteUInt32(n uint32) error {
-	if err := t.ensureWriteable(4); err != nil {
-		return err
-	}
-
-	t.writeUInt32(n)
-	return nil
+func (t *Buffer) PrependUInt32(n uint32) error {
+	return t.prependUInt32Order(n, t.order)
 }
 
-func (t *Buffer) WriteInt32(n int32) error {
-	return t.WriteUInt32(uint32(n))
+func (t *Buffer) PrependUInt64(n uint64) error {
+	return t.prependUInt64Order(n, t.order)
 }
 
-func (t *Buffer) WriteUInt64(n uint64) error {
-	if err := t.ensureWriteable(8); err != nil {
-		return err
+func (t *Buffer) WriteFromFd(fd int) (int, error) {
+	tail, end, err := t.writableTail()
+	if err != nil {
+		return 0, err
 	}
 
-	t.writeUInt64(n)
-	return nil
-}
+	n, err := syscall.Read(fd, tail.buf[tail.w:end])
+	if n < 0 {
+		n = 0
+	}
+	tail.w += n
+	t.size += n
 
-func (t *Buffer) WriteInt64(n int64) error {
-	return t.WriteUInt64(uint64(n))
+	return n, err
 }
 
-func (t *Buffer) WriteInt(n int) error {
-	return t.WriteInt64(int64(n))
-}
+// ReadFrom reads from r into node memory directly until io.EOF, which is not reported
+// as an error. ErrExceedMaximumSize is returned once MaxSize is reached.
+func (t *Buffer) ReadFrom(r io.Reader) (n int64, err error) {
+	for {
+		tail, end, e0 := t.writableTail()
+		if e0 != nil {
+			return n, e0
+		}
 
-func (t *Buffer) WriteUInt(n uint) error {
-	return t.WriteUInt64(uint64(n))
-}
+		n0, e0 := r.Read(tail.buf[tail.w:end])
+		if n0 < 0 {
+			panic("reader returned negative count from Read")
+		}
+		tail.w += n0
+		t.size += n0
+		n += int64(n0)
 
-func (t *Buffer) WriteString(s string) error {
-	data := stringToBytes(s)
-	return t.WriteBytes(data)
+		if e0 == io.EOF {
+			return n, nil
+		}
+		if e0 != nil {
+			return n, e0
+		}
+	}
 }
 
-func (t *Buffer) Write(p []byte) (int, error) {
-	if err := t.WriteBytes(p); err != nil {
+// WriteFromFdv reads up to hint bytes from fd with a single readv, scattering them
+// over the free space of the tail node and as many fresh nodes as needed. Fresh
+// nodes which receive no data are returned to the pool.
+func (t *Buffer) WriteFromFdv(fd int, hint int) (int, error) {
+	if err := t.ensureWriteable(1); err != nil {
 		return 0, err
 	}
 
-	return len(p), nil
-}
+	if hint <= 0 {
+		hint = t.minAllocSize
+	}
+	if t.maxSize > 0 && t.maxSize-t.size < hint {
+		hint = t.maxSize - t.size
+	}
 
-func (t *Buffer) WriteFromFd(fd int) (int, error) {
-	if err := t.ensureWriteable(1); err != nil {
-		return 0, err
+	remain := hint
+	tail := t.writer()
+	if tail != nil && tail.WritableBytes() > 0 {
+		end := tail.Cap()
+		if end-tail.w > remain {
+			end = tail.w + remain
+		}
+		t.iovs = append(t.iovs, tail.buf[tail.w:end])
+		remain -= end - tail.w
+	} else {
+		tail = nil
 	}
 
-	if t.nodes == nil || t.nc == 0 || t.nodes[len(t.nodes)-1].WritableBytes() == 0 {
+	var fresh []*node
+	for remain > 0 && len(t.iovs) < maxIovecs {
 		size := t.minAllocSize
-		if t.maxSize > 0 && t.maxSize-t.size < t.minAllocSize {
-			size = t.maxSize - t.size
+		if size > remain {
+			size = remain
 		}
 
-		t.addNode(newNode(size))
+		var no *node
+		if tail == nil && len(fresh) == 0 {
+			no = t.allocNode(size)
+		} else {
+			no = t.spareNode(size)
+		}
+		fresh = append(fresh, no)
+		t.iovs = append(t.iovs, no.buf[no.w:no.w+size])
+		remain -= size
 	}
 
-	tail := t.nodes[t.nc-1]
-	end := tail.Cap()
-	if t.maxSize > 0 && t.maxSize-t.size < (end-tail.w) {
-		end = tail.w + t.maxSize - t.size
+	n, err := unix.Readv(fd, t.iovs)
+	if n < 0 {
+		n = 0
 	}
 
-	n, err := syscall.Read(fd, tail.buf[tail.w:end])
-	tail.w += n
+	rest := n
+	iovs := t.iovs
+	if tail != nil {
+		used := len(iovs[0])
+		if used > rest {
+			used = rest
+		}
+		tail.w += used
+		rest -= used
+		iovs = iovs[1:]
+	}
+	for i, no := range fresh {
+		if rest == 0 {
+			t.retire(no)
+			continue
+		}
+
+		used := len(iovs[i])
+		if used > rest {
+			used = rest
+		}
+		no.w += used
+		rest -= used
+		t.addNode(no)
+	}
+	t.clearIovs()
 	t.size += n
 
 	return n, err
@@ -408,86 +484,118 @@ func (t *Buffer) Len() int {
 	return t.size
 }
 
-func (t *Buffer) Skip(n int) error {
-	if err := t.ensureReadable(n); err != nil {
-		return err
+// Trim returns the drained nodes retained for reuse, see Options.RetainSize, to the pool.
+func (t *Buffer) Trim() {
+	for i, no := range t.spare {
+		no.Release()
+		t.spare[i] = nil
 	}
-
-	t.skip(n)
-	return nil
+	t.spare = t.spare[:0]
+	t.spareSize = 0
 }
 
 func (t *Buffer) Release() {
-	if t.nodes != nil {
-		for _, n := range t.nodes {
-			n.Release()
-		}
-		t.nodes = nil
+	for i := 0; i < t.nc; i++ {
+		t.nodeAt(i).Release()
 	}
+	t.nodes = nil
+	t.head = 0
 	t.nc = 0
+	t.Trim()
+	t.forward(t.size) //invalidates outstanding placeholders and frames
 	t.size = 0
+	t.lastSize = 0
+	t.marked = false
+	t.mark = 0
 }
 
 //#endregion
 
 func (t *Buffer) addNode(n *node) {
-	t.expand()
+	t.grow(1)
 
-	t.nodes[t.nc] = n
+	t.setNodeAt(t.nc, n)
 	t.nc++
 
-	t.adjust()
+	t.adjust(t.nc - 1)
+}
+
+// nodeAt returns the i-th node counted from the head of the ring.
+func (t *Buffer) nodeAt(i int) *node {
+	return t.nodes[(t.head+i)&(len(t.nodes)-1)]
 }
 
-func (t *Buffer) expand() {
-	if t.nodes == nil {
-		t.nodes = make([]*node, 1)
+func (t *Buffer) setNodeAt(i int, n *node) {
+	t.nodes[(t.head+i)&(len(t.nodes)-1)] = n
+}
+
+// grow makes room for k more nodes. The ring is unwrapped into one twice as large.
+func (t *Buffer) grow(k int) {
+	if t.nc+k <= len(t.nodes) {
 		return
 	}
 
-	if t.nc == len(t.nodes) {
-		s := len(t.nodes) << 1
-		nodes := make([]*node, s)
+	s := len(t.nodes) << 1
+	if s == 0 {
+		s = 1
+	}
+	for s < t.nc+k {
+		s <<= 1
+	}
+	nodes := make([]*node, s)
 
-		copy(nodes, t.nodes)
-		t.nodes = nodes
-		return
+	for i := 0; i < t.nc; i++ {
+		nodes[i] = t.nodeAt(i)
 	}
+	t.nodes = nodes
+	t.head = 0
 }
 
 func (t *Buffer) shrink() {
-	if t.nodes == nil || t.nodes[0].ReadableBytes() > 0 {
+	if t.marked {
 		return
 	}
 
-	l := 0
-	r := 0
-	n := t.nc
-	for r < n {
-		if t.nodes[r].ReadableBytes() <= 0 {
-			t.nodes[r].Release()
-			t.nc--
-		} else {
-			t.nodes[l] = t.nodes[r]
-			l++
-		}
-		r++
+	for t.nc > 0 && t.nodeAt(0).ReadableBytes() <= 0 {
+		t.retire(t.popFront())
 	}
+}
+
+// popFront removes the head node without releasing it.
+func (t *Buffer) popFront() *node {
+	no := t.nodeAt(0)
+	t.setNodeAt(0, nil)
+	t.head = (t.head + 1) & (len(t.nodes) - 1)
+	t.nc--
+	return no
+}
 
-	for l < len(t.nodes) {
-		t.nodes[l] = nil
-		l++
+// clearIovs drops the references to node memory held by t.iovs, keeping its capacity.
+func (t *Buffer) clearIovs() {
+	for i := range t.iovs {
+		t.iovs[i] = nil
 	}
+	t.iovs = t.iovs[:0]
 }
 
-func (t *Buffer) adjust() {
-	if t.nc == 0 {
-		return
+// detach removes the first k nodes without releasing them.
+func (t *Buffer) detach(k int) {
+	for ; k > 0; k-- {
+		t.popFront()
 	}
+}
 
-	t.nodes[0].adj = 0
-	for i := 1; i < t.nc; i++ {
-		t.nodes[i].adj = t.nodes[i-1].adj + t.nodes[i-1].ReadableBytes()
+// adjust recomputes the stream offsets of the nodes from the i-th one on. The nodes
+// follow each other without gaps, and the read position is at offset t.off.
+func (t *Buffer) adjust(i int) {
+	for ; i < t.nc; i++ {
+		no := t.nodeAt(i)
+		if i == 0 {
+			no.off = t.off - int64(no.r)
+		} else {
+			prev := t.nodeAt(i - 1)
+			no.off = prev.off + int64(prev.w-no.h)
+		}
 	}
 }
 
@@ -499,20 +607,45 @@ func (t *Buffer) ensureWriteable(size int) error {
 	return nil
 }
 
+// writableTail makes sure the tail node has free space, allocating a node if needed,
+// and returns it with the end of the region which may be written within MaxSize.
+func (t *Buffer) writableTail() (*node, int, error) {
+	if err := t.ensureWriteable(1); err != nil {
+		return nil, 0, err
+	}
+
+	if t.writer() == nil || t.writer().WritableBytes() == 0 {
+		size := t.minAllocSize
+		if t.maxSize > 0 && t.maxSize-t.size < t.minAllocSize {
+			size = t.maxSize - t.size
+		}
+
+		t.addNode(t.allocNode(size))
+	}
+
+	tail := t.writer()
+	end := tail.Cap()
+	if t.maxSize > 0 && t.maxSize-t.size < (end-tail.w) {
+		end = tail.w + t.maxSize - t.size
+	}
+
+	return tail, end, nil
+}
+
 func (t *Buffer) writer() *node {
 	if t.nc == 0 {
 		return nil
 	}
 
-	return t.nodes[t.nc-1]
+	return t.nodeAt(t.nc - 1)
 }
 
 func (t *Buffer) reader() *node {
-	if t.nodes == nil {
+	if t.nc == 0 {
 		return nil
 	}
 
-	return t.nodes[0]
+	return t.nodeAt(0)
 }
 
 func (t *Buffer) ensureReadable(size int) error {
@@ -525,70 +658,192 @@ func (t *Buffer) ensureReadable(size int) error {
 	return nil
 }
 
-func (t *Buffer) writeUInt8(n uint8) {
-	if t.writer() == nil || t.writer().WritableBytes() < 1 {
-		t.addNode(newNode(t.minAllocSize))
+func (t *Buffer) prependUInt16Order(n uint16, order binary.ByteOrder) error {
+	var b [2]byte
+	order.PutUint16(b[:], n)
+	return t.Prepend(b[:])
+}
+
+func (t *Buffer) prependUInt32Order(n uint32, order binary.ByteOrder) error {
+	var b [4]byte
+	order.PutUint32(b[:], n)
+	return t.Prepend(b[:])
+}
+
+func (t *Buffer) prependUInt64Order(n uint64, order binary.ByteOrder) error {
+	var b [8]byte
+	order.PutUint64(b[:], n)
+	return t.Prepend(b[:])
+}
+
+// write appends b to the tail node, allocating a node for what does not fit.
+func (t *Buffer) write(b []byte) {
+	w := t.writer()
+	if w != nil && w.WritableBytes() > 0 {
+		n := copy(w.buf[w.w:], b)
+		w.w += n
+		t.size += n
+
+		if n == len(b) {
+			return
+		}
+		b = b[n:]
+	}
+
+	s := len(b)
+	if s < t.minAllocSize {
+		s = t.minAllocSize
+	}
+	node := t.allocNode(s)
+	n := copy(node.buf[node.w:], b)
+	node.w += n
+	t.addNode(node)
+	t.size += n
+}
+
+// unread puts p, the bytes consumed last, back in front of the read position.
+func (t *Buffer) unread(p []byte) error {
+	if err := t.ensureWriteable(len(p)); err != nil {
+		return err
+	}
+
+	if t.marked {
+		t.rewind(len(p))
+		t.mark -= len(p)
+		return nil
 	}
 
-	t.writer().buf[t.writer().w] = n
-	t.writer().w++
-	t.size++
+	t.prepend(p)
+	return nil
 }
 
-func (t *Buffer) writeUInt16(n uint16) {
-	if t.writer() != nil && t.writer().WritableBytes() >= 2 {
-		t.writer().buf[t.writer().w] = uint8(n >> 8)
-		t.writer().buf[t.writer().w+1] = uint8(n)
+// prepend writes p in front of the read position, into the free space in front of
+// the head node when there is enough, otherwise into a new node inserted in front
+// whose data is placed at its end to leave room for further prepends.
+func (t *Buffer) prepend(p []byte) {
+	t.off -= int64(len(p))
+	if t.nc == 0 {
+		t.WriteBytes(p)
+		return
+	}
 
-		t.writer().w += 2
-		t.size += 2
+	if no := t.nodeAt(0); no.r >= len(p) && !no.Shared() {
+		no.r -= len(p)
+		copy(no.buf[no.r:], p)
+		if no.h > no.r {
+			no.h = no.r
+		}
 	} else {
-		t.writeUInt8(uint8(n >> 8))
-		t.writeUInt8(uint8(n))
+		no := t.spareNode(len(p) + t.headroom)
+		no.w = no.Cap()
+		no.r = no.w - len(p)
+		no.h = no.r
+		copy(no.buf[no.r:], p)
+		head := t.nodeAt(0)
+		head.h = head.r //the consumed bytes are no longer in front of the data
+		t.insertAt(0, no)
+		no.off = t.off - int64(no.r)
 	}
+
+	t.size += len(p)
 }
 
-func (t *Buffer) writeUInt32(n uint32) {
-	if t.writer() != nil && t.writer().WritableBytes() >= 4 {
-		t.writer().buf[t.writer().w] = uint8(n >> 24)
-		t.writer().buf[t.writer().w+1] = uint8(n >> 16)
-		t.writer().buf[t.writer().w+2] = uint8(n >> 8)
-		t.writer().buf[t.writer().w+3] = uint8(n)
+// allocNode returns a node for size bytes to be appended. The first node of an empty
+// buffer gets Options.Headroom free bytes in front of its data for Prepend.
+func (t *Buffer) allocNode(size int) *node {
+	if t.nc > 0 || t.headroom == 0 {
+		return t.spareNode(size)
+	}
 
-		t.writer().w += 4
-		t.size += 4
-	} else {
-		t.writeUInt16(uint16(n >> 16))
-		t.writeUInt16(uint16(n))
+	no := t.spareNode(size + t.headroom)
+	no.r = t.headroom
+	no.w = t.headroom
+	no.h = t.headroom
+	return no
+}
+
+// spareNode returns a retained node with room for size bytes, or a new node from the
+// pool when there is none.
+func (t *Buffer) spareNode(size int) *node {
+	for i, no := range t.spare {
+		if no.Cap() < size {
+			continue
+		}
+
+		last := len(t.spare) - 1
+		t.spare[i] = t.spare[last]
+		t.spare[last] = nil
+		t.spare = t.spare[:last]
+		t.spareSize -= no.Cap()
+		return no
+	}
+
+	return newNode(size)
+}
+
+// retire releases a node which is no longer part of the buffer, unless it fits in
+// Options.RetainSize and is kept for reuse. Shared nodes are never retained, their
+// free space cannot be written.
+func (t *Buffer) retire(no *node) {
+	if no.Shared() || t.spareSize+no.Cap() > t.retainSize {
+		no.Release()
+		return
 	}
+
+	no.r = 0
+	no.w = 0
+	no.h = 0
+	no.off = 0
+	t.spare = append(t.spare, no)
+	t.spareSize += no.Cap()
 }
 
-func (t *Buffer) writeUInt64(n uint64) {
-	if t.writer() != nil && t.writer().WritableBytes() >= 8 {
-		t.writer().buf[t.writer().w] = uint8(n >> 56)
-		t.writer().buf[t.writer().w+1] = uint8(n >> 48)
-		t.writer().buf[t.writer().w+2] = uint8(n >> 40)
-		t.writer().buf[t.writer().w+3] = uint8(n >> 32)
-		t.writer().buf[t.writer().w+4] = uint8(n >> 24)
-		t.writer().buf[t.writer().w+5] = uint8(n >> 16)
-		t.writer().buf[t.writer().w+6] = uint8(n >> 8)
-		t.writer().buf[t.writer().w+7] = uint8(n)
+// insertAt inserts nodes before the i-th node.
+func (t *Buffer) insertAt(i int, nodes ...*node) {
+	k := len(nodes)
+	t.grow(k)
 
-		t.writer().w += 8
-		t.size += 8
+	if i == 0 {
+		t.head = (t.head - k) & (len(t.nodes) - 1)
 	} else {
-		t.writeUInt32(uint32(n >> 32))
-		t.writeUInt32(uint32(n))
+		for j := t.nc - 1; j >= i; j-- {
+			t.setNodeAt(j+k, t.nodeAt(j))
+		}
 	}
+	t.nc += k
+
+	for j, no := range nodes {
+		t.setNodeAt(i+j, no)
+	}
+}
+
+// removeAt releases k nodes starting from the i-th node.
+func (t *Buffer) removeAt(i int, k int) {
+	for j := i; j < i+k; j++ {
+		t.retire(t.nodeAt(j))
+	}
+
+	for j := i; j+k < t.nc; j++ {
+		t.setNodeAt(j, t.nodeAt(j+k))
+	}
+	for j := t.nc - k; j < t.nc; j++ {
+		t.setNodeAt(j, nil)
+	}
+	t.nc -= k
 }
 
 func (t *Buffer) skip(n int) {
 	t.size -= n
+	t.forward(n)
+	t.lastSize = 0
+	if t.marked {
+		t.mark += n
+	}
 
 	i := 0
 	var no *node
 	for n > 0 {
-		no = t.nodes[i]
+		no = t.nodeAt(i)
 		avail := no.ReadableBytes()
 		if avail > n {
 			no.r += n
@@ -601,85 +856,89 @@ func (t *Buffer) skip(n int) {
 	}
 
 	t.shrink()
-	t.adjust()
 }
 
-func (t *Buffer) getUInt8(idx int) uint8 {
-	n, i := t.getNode(idx)
-	return t.nodes[n].buf[i]
+// forward moves the stream offset of the read position n bytes forward.
+func (t *Buffer) forward(n int) {
+	t.off += int64(n)
+	if t.off > t.consumed {
+		t.consumed = t.off
+	}
 }
 
-func (t *Buffer) getUInt16(idx int) uint16 {
+// peek returns len(b) bytes starting at idx. The bytes are returned in place when they
+// live in a single node, otherwise they are gathered into b.
+func (t *Buffer) peek(idx int, b []byte) []byte {
 	n, i := t.getNode(idx)
-	if i <= t.nodes[n].Cap()-2 {
-		return (uint16(t.nodes[n].buf[i]) << 8) | uint16(t.nodes[n].buf[i+1])
-	} else {
-		return (uint16(t.getUInt8(idx)) << 8) | uint16(t.getUInt8(idx+1))
+	if no := t.nodeAt(n); no.w-i >= len(b) {
+		return no.buf[i : i+len(b)]
 	}
+
+	t.copyBytes(idx, b)
+	return b
 }
 
-func (t *Buffer) getUInt32(idx int) uint32 {
-	n, i := t.getNode(idx)
-	if i <= t.nodes[n].Cap()-4 {
-		return (uint32(t.nodes[n].buf[i]) << 24) |
-			(uint32(t.nodes[n].buf[i+1]) << 16) |
-			(uint32(t.nodes[n].buf[i+2]) << 8) |
-			uint32(t.nodes[n].buf[i+3])
-	} else {
-		return (uint32(t.getUInt16(idx)) << 16) | uint32(t.getUInt16(idx+2))
+func (t *Buffer) copyBytes(idx int, p []byte) {
+	if len(p) == 0 {
+		return
 	}
-}
 
-func (t *Buffer) getUInt64(idx int) uint64 {
-	n, i := t.getNode(idx)
-	if i <= t.nodes[n].Cap()-8 {
-		return (uint64(t.nodes[n].buf[i]) << 56) |
-			(uint64(t.nodes[n].buf[i+1]) << 48) |
-			(uint64(t.nodes[n].buf[i+2]) << 40) |
-			(uint64(t.nodes[n].buf[i+3]) << 32) |
-			(uint64(t.nodes[n].buf[i+4]) << 24) |
-			(uint64(t.nodes[n].buf[i+5]) << 16) |
-			(uint64(t.nodes[n].buf[i+6]) << 8) |
-			uint64(t.nodes[n].buf[i+7])
-	} else {
-		return (uint64(t.getUInt32(idx)) << 32) | uint64(t.getUInt32(idx+4))
+	i, ni := t.getNode(idx)
+	ri := 0
+	for ri < len(p) {
+		no := t.nodeAt(i)
+		ri += copy(p[ri:], no.buf[ni:no.w])
+
+		i++
+		if i < t.nc {
+			ni = t.nodeAt(i).r
+		}
 	}
 }
 
-func (t *Buffer) getBytes(idx int, size int) []byte {
-	res := make([]byte, size)
+// setBytes overwrites readable bytes starting at idx, spanning nodes as needed.
+func (t *Buffer) setBytes(idx int, p []byte) {
+	if len(p) == 0 {
+		return
+	}
 
 	i, ni := t.getNode(idx)
-	ri := 0
-	for ri < size {
-		no := t.nodes[i]
-		ri += copy(res[ri:], no.buf[ni:no.w])
+	wi := 0
+	for wi < len(p) {
+		no := t.nodeAt(i)
+		wi += copy(no.buf[ni:no.w], p[wi:])
 
 		i++
-		ni = 0
+		if i < t.nc {
+			ni = t.nodeAt(i).r
+		}
 	}
-
-	return res
 }
 
+// getNode returns the node holding the idx-th readable byte and its index in the
+// node's buf, found by the stream offsets of the nodes.
 func (t *Buffer) getNode(idx int) (int, int) {
+	pos := t.off + int64(idx)
+
 	l, r := 0, t.nc
-	var m int
 	for l < r {
-		m = (l >> 1) + (r >> 1)
-
-		n := t.nodes[m]
-
-		if n.adj <= idx && n.adj+n.ReadableBytes()-1 >= idx {
-			return m, idx - n.adj + n.r
-		} else if n.adj > idx {
-			r = m
-		} else {
+		m := int(uint(l+r) >> 1)
+		if no := t.nodeAt(m); no.off+int64(no.w) <= pos {
 			l = m + 1
+		} else {
+			r = m
 		}
 	}
+	if l == t.nc {
+		return -1, -1
+	}
 
-	return -1, -1
+	return l, int(pos - t.nodeAt(l).off)
+}
+
+// indexOf returns the readable index of no.buf[i].
+func (t *Buffer) indexOf(no *node, i int) int {
+	return int(no.off + int64(i) - t.off)
 }
 
 func New() *Buffer {
@@ -687,6 +946,18 @@ func New() *Buffer {
 		maxSize:      0,
 		minAllocSize: defaultMinAllocSize,
 	}
+	buf.accessor = accessor{buf, binary.BigEndian}
+	return buf
+}
+
+// newLike returns an empty Buffer which allocates and encodes like t, without MaxSize.
+func newLike(t *Buffer) *Buffer {
+	buf := &Buffer{
+		minAllocSize: t.minAllocSize,
+		maxLineSize:  t.maxLineSize,
+		headroom:     t.headroom,
+	}
+	buf.accessor = accessor{buf, t.order}
 	return buf
 }
 
@@ -697,10 +968,28 @@ func NewWithOptions(opt Options) *Buffer {
 	if opt.MinAllocSize <= 0 {
 		panic("MinAllocSize should be positive")
 	}
+	if opt.MaxLineSize < 0 {
+		panic("MaxLineSize cannot be negative")
+	}
+	if opt.Headroom < 0 {
+		panic("Headroom cannot be negative")
+	}
+	if opt.RetainSize < 0 {
+		panic("RetainSize cannot be negative")
+	}
+
+	order := opt.ByteOrder
+	if order == nil {
+		order = binary.BigEndian
+	}
 
 	buf := &Buffer{
 		maxSize:      opt.MaxSize,
 		minAllocSize: opt.MinAllocSize,
+		maxLineSize:  opt.MaxLineSize,
+		headroom:     opt.Headroom,
+		retainSize:   opt.RetainSize,
 	}
+	buf.accessor = accessor{buf, order}
 	return buf
 }
@@ -1,272 +1,381 @@
 package buffer
 
 import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
 	"golang.org/x/sys/unix"
+	"io"
 	"math/rand"
+	"net"
 	"os"
+	"regexp"
+	"strconv"
 	"testing"
+	"unicode/utf8"
 )
 
-func TestBuffer_Len(t *testing.T) {
-	buf := New()
+// testBuffer gives the shared tests the ByteBuffer methods and the typed accessors
+// of either buffer type.
+type testBuffer struct {
+	ByteBuffer
+	*accessor
+}
 
-	buf.WriteInt64(1)
-	if buf.Len() != 8 {
-		t.Fail()
-	}
+func (t testBuffer) ReadByte() (byte, error) {
+	return t.ByteBuffer.ReadByte()
+}
 
-	buf.WriteInt32(1)
-	if buf.Len() != 12 {
-		t.Fail()
+func (t testBuffer) Write(p []byte) (int, error) {
+	return t.ByteBuffer.Write(p)
+}
+
+func (t testBuffer) WriteByte(c byte) error {
+	return t.ByteBuffer.WriteByte(c)
+}
+
+func (t testBuffer) Skip(n int) error {
+	return t.ByteBuffer.Skip(n)
+}
+
+// testBuffers lists the buffer types the shared tests run against. The RingBuffer
+// gets MaxSize as its capacity, and when MinAllocSize is set its data starts
+// MinAllocSize bytes before the end of the ring, so that it wraps where a Buffer
+// would start a new node.
+var testBuffers = []struct {
+	name string
+	new  func(opt Options) testBuffer
+}{
+	{"Buffer", func(opt Options) testBuffer {
+		if opt.MinAllocSize == 0 {
+			opt.MinAllocSize = defaultMinAllocSize
+		}
+		buf := NewWithOptions(opt)
+		return testBuffer{buf, &buf.accessor}
+	}},
+	{"RingBuffer", func(opt Options) testBuffer {
+		size := opt.MaxSize
+		if size == 0 {
+			size = 1 << 16
+		}
+		buf := NewRingWithOptions(Options{
+			MaxSize:   size,
+			ByteOrder: opt.ByteOrder,
+		})
+		if opt.MinAllocSize > 0 && opt.MinAllocSize < size {
+			buf.WriteBytes(make([]byte, size-opt.MinAllocSize))
+			buf.Skip(size - opt.MinAllocSize)
+		}
+		return testBuffer{buf, &buf.accessor}
+	}},
+}
+
+func runBuffers(t *testing.T, f func(t *testing.T, newBuf func(Options) testBuffer)) {
+	for _, tb := range testBuffers {
+		t.Run(tb.name, func(t *testing.T) {
+			f(t, tb.new)
+		})
 	}
 }
 
+func TestBuffer_Len(t *testing.T) {
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{})
+
+		buf.WriteInt64(1)
+		if buf.Len() != 8 {
+			t.Fail()
+		}
+
+		buf.WriteInt32(1)
+		if buf.Len() != 12 {
+			t.Fail()
+		}
+	})
+}
+
 func TestBuffer_WriteUInt8(t *testing.T) {
-	buf := New()
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{})
 
-	if err := buf.WriteUInt8(1); err != nil {
-		t.Fail()
-	}
+		if err := buf.WriteUInt8(1); err != nil {
+			t.Fail()
+		}
 
-	if n, err := buf.ReadUInt8(); err != nil || n != 1 {
-		t.Fail()
-	}
+		if n, err := buf.ReadUInt8(); err != nil || n != 1 {
+			t.Fail()
+		}
 
-	if _, err := buf.ReadUInt8(); err != ErrNoEnoughData {
-		t.Fail()
-	}
+		if _, err := buf.ReadUInt8(); err != ErrNoEnoughData {
+			t.Fail()
+		}
+	})
 }
 
 func TestBuffer_WriteInt8(t *testing.T) {
-	buf := New()
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{})
 
-	if err := buf.WriteInt8(1); err != nil {
-		t.Fail()
-	}
+		if err := buf.WriteInt8(1); err != nil {
+			t.Fail()
+		}
 
-	if n, err := buf.ReadInt8(); err != nil || n != 1 {
-		t.Fail()
-	}
+		if n, err := buf.ReadInt8(); err != nil || n != 1 {
+			t.Fail()
+		}
 
-	if _, err := buf.ReadInt8(); err != ErrNoEnoughData {
-		t.Fail()
-	}
+		if _, err := buf.ReadInt8(); err != ErrNoEnoughData {
+			t.Fail()
+		}
+	})
 }
 
 func TestBuffer_WriteBool(t *testing.T) {
-	buf := New()
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{})
 
-	if err := buf.WriteBool(true); err != nil {
-		t.Fail()
-	}
+		if err := buf.WriteBool(true); err != nil {
+			t.Fail()
+		}
 
-	if err := buf.WriteBool(false); err != nil {
-		t.Fail()
-	}
+		if err := buf.WriteBool(false); err != nil {
+			t.Fail()
+		}
 
-	if n, err := buf.ReadBool(); err != nil || !n {
-		t.Fail()
-	}
+		if n, err := buf.ReadBool(); err != nil || !n {
+			t.Fail()
+		}
 
-	if n, err := buf.ReadBool(); err != nil || n {
-		t.Fail()
-	}
+		if n, err := buf.ReadBool(); err != nil || n {
+			t.Fail()
+		}
 
-	if _, err := buf.ReadBool(); err != ErrNoEnoughData {
-		t.Fail()
-	}
+		if _, err := buf.ReadBool(); err != ErrNoEnoughData {
+			t.Fail()
+		}
+	})
 }
 
 func TestBuffer_ReadByte(t *testing.T) {
-	buf := New()
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{})
 
-	if err := buf.WriteByte(1); err != nil {
-		t.Fail()
-	}
+		if err := buf.WriteByte(1); err != nil {
+			t.Fail()
+		}
 
-	if n, err := buf.ReadByte(); err != nil || n != 1 {
-		t.Fail()
-	}
+		if n, err := buf.ReadByte(); err != nil || n != 1 {
+			t.Fail()
+		}
 
-	if _, err := buf.ReadByte(); err != ErrNoEnoughData {
-		t.Fail()
-	}
+		if _, err := buf.ReadByte(); err != ErrNoEnoughData {
+			t.Fail()
+		}
+	})
 }
 
 func TestBuffer_WriteUInt16(t *testing.T) {
-	buf := New()
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{})
 
-	num := uint16(rand.Int())
+		num := uint16(rand.Int())
 
-	if err := buf.WriteUInt16(num); err != nil {
-		t.Fail()
-	}
+		if err := buf.WriteUInt16(num); err != nil {
+			t.Fail()
+		}
 
-	if n := buf.getUInt16(0); n != num {
-		t.Fail()
-	}
+		if n, err := buf.GetUInt16(0); err != nil || n != num {
+			t.Fail()
+		}
 
-	n1, _ := buf.ReadUInt8()
-	n2, _ := buf.ReadUInt8()
+		n1, _ := buf.ReadUInt8()
+		n2, _ := buf.ReadUInt8()
 
-	num2 := (uint16(n1) << 8) | uint16(n2)
-	if num != num2 {
-		t.Fail()
-	}
+		num2 := (uint16(n1) << 8) | uint16(n2)
+		if num != num2 {
+			t.Fail()
+		}
+	})
 }
 
 func TestBuffer_WriteInt16(t *testing.T) {
-	buf := New()
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{})
 
-	if err := buf.WriteInt16(1); err != nil {
-		t.Fail()
-	}
+		if err := buf.WriteInt16(1); err != nil {
+			t.Fail()
+		}
 
-	if n, err := buf.ReadInt16(); err != nil || n != 1 {
-		t.Fail()
-	}
+		if n, err := buf.ReadInt16(); err != nil || n != 1 {
+			t.Fail()
+		}
 
-	if _, err := buf.ReadInt16(); err != ErrNoEnoughData {
-		t.Fail()
-	}
+		if _, err := buf.ReadInt16(); err != ErrNoEnoughData {
+			t.Fail()
+		}
+	})
 }
 
 func TestBuffer_WriteUInt32(t *testing.T) {
-	buf := New()
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{})
 
-	if err := buf.WriteUInt32(1); err != nil {
-		t.Fail()
-	}
+		if err := buf.WriteUInt32(1); err != nil {
+			t.Fail()
+		}
 
-	if n, err := buf.ReadUInt32(); err != nil || n != 1 {
-		t.Fail()
-	}
+		if n, err := buf.ReadUInt32(); err != nil || n != 1 {
+			t.Fail()
+		}
 
-	if _, err := buf.ReadUInt32(); err != ErrNoEnoughData {
-		t.Fail()
-	}
+		if _, err := buf.ReadUInt32(); err != ErrNoEnoughData {
+			t.Fail()
+		}
+	})
 }
 
 func TestBuffer_WriteInt32(t *testing.T) {
-	buf := New()
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{})
 
-	if err := buf.WriteInt32(1); err != nil {
-		t.Fail()
-	}
+		if err := buf.WriteInt32(1); err != nil {
+			t.Fail()
+		}
 
-	if n, err := buf.ReadInt32(); err != nil || n != 1 {
-		t.Fail()
-	}
+		if n, err := buf.ReadInt32(); err != nil || n != 1 {
+			t.Fail()
+		}
 
-	if _, err := buf.ReadInt32(); err != ErrNoEnoughData {
-		t.Fail()
-	}
+		if _, err := buf.ReadInt32(); err != ErrNoEnoughData {
+			t.Fail()
+		}
+	})
 }
 
 func TestBuffer_WriteUInt64(t *testing.T) {
-	buf := New()
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{})
 
-	if err := buf.WriteUInt64(1); err != nil {
-		t.Fail()
-	}
+		if err := buf.WriteUInt64(1); err != nil {
+			t.Fail()
+		}
 
-	if n, err := buf.ReadUInt64(); err != nil || n != 1 {
-		t.Fail()
-	}
+		if n, err := buf.ReadUInt64(); err != nil || n != 1 {
+			t.Fail()
+		}
 
-	if _, err := buf.ReadUInt64(); err != ErrNoEnoughData {
-		t.Fail()
-	}
+		if _, err := buf.ReadUInt64(); err != ErrNoEnoughData {
+			t.Fail()
+		}
+	})
 }
 
 func TestBuffer_WriteInt64(t *testing.T) {
-	buf := New()
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{})
 
-	if err := buf.WriteInt64(1); err != nil {
-		t.Fail()
-	}
+		if err := buf.WriteInt64(1); err != nil {
+			t.Fail()
+		}
 
-	if n, err := buf.ReadInt64(); err != nil || n != 1 {
-		t.Fail()
-	}
+		if n, err := buf.ReadInt64(); err != nil || n != 1 {
+			t.Fail()
+		}
 
-	if _, err := buf.ReadInt64(); err != ErrNoEnoughData {
-		t.Fail()
-	}
+		if _, err := buf.ReadInt64(); err != ErrNoEnoughData {
+			t.Fail()
+		}
+	})
 }
 
 func TestBuffer_WriteUInt(t *testing.T) {
-	buf := New()
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{})
 
-	if err := buf.WriteUInt(1); err != nil {
-		t.Fail()
-	}
+		if err := buf.WriteUInt(1); err != nil {
+			t.Fail()
+		}
 
-	if n, err := buf.ReadUInt(); err != nil || n != 1 {
-		t.Fail()
-	}
+		if n, err := buf.ReadUInt(); err != nil || n != 1 {
+			t.Fail()
+		}
 
-	if _, err := buf.ReadUInt(); err != ErrNoEnoughData {
-		t.Fail()
-	}
+		if _, err := buf.ReadUInt(); err != ErrNoEnoughData {
+			t.Fail()
+		}
+	})
 }
 
 func TestBuffer_WriteInt(t *testing.T) {
-	buf := New()
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{})
 
-	if err := buf.WriteInt(1); err != nil {
-		t.Fail()
-	}
+		if err := buf.WriteInt(1); err != nil {
+			t.Fail()
+		}
 
-	if n, err := buf.ReadInt(); err != nil || n != 1 {
-		t.Fail()
-	}
+		if n, err := buf.ReadInt(); err != nil || n != 1 {
+			t.Fail()
+		}
 
-	if _, err := buf.ReadInt(); err != ErrNoEnoughData {
-		t.Fail()
-	}
+		if _, err := buf.ReadInt(); err != ErrNoEnoughData {
+			t.Fail()
+		}
+	})
 }
 
 func TestBuffer_HalfReadWrite(t *testing.T) {
-	defaultMinAllocSize = 1
-	buf := New()
-
-	if err := buf.WriteInt64(1); err != nil {
-		t.Fail()
-	}
-
-	if n, err := buf.ReadInt64(); err != nil || n != 1 {
-		t.Fail()
-	}
-
-	if err := buf.WriteUInt64(1); err != nil {
-		t.Fail()
-	}
-
-	if n, err := buf.ReadUInt64(); err != nil || n != 1 {
-		t.Fail()
-	}
-
-	if _, err := buf.ReadInt64(); err != ErrNoEnoughData {
-		t.Fail()
-	}
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{
+			MinAllocSize: 1,
+		})
+
+		if err := buf.WriteInt64(1); err != nil {
+			t.Fail()
+		}
+
+		if n, err := buf.ReadInt64(); err != nil || n != 1 {
+			t.Fail()
+		}
+
+		if err := buf.WriteUInt64(1); err != nil {
+			t.Fail()
+		}
+
+		if n, err := buf.ReadUInt64(); err != nil || n != 1 {
+			t.Fail()
+		}
+
+		if _, err := buf.ReadInt64(); err != ErrNoEnoughData {
+			t.Fail()
+		}
+	})
 }
 
 func TestBuffer_Release(t *testing.T) {
-	buf := New()
-
-	buf.WriteInt64(1)
-
-	buf.Release()
-	if buf.nodes != nil {
-		t.Fail()
-	}
-	if buf.nc != 0 {
-		t.Fail()
-	}
-	if buf.size != 0 {
-		t.Fail()
-	}
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{})
+
+		buf.WriteInt64(1)
+
+		buf.Release()
+		switch b := buf.ByteBuffer.(type) {
+		case *Buffer:
+			if b.nodes != nil {
+				t.Fail()
+			}
+			if b.nc != 0 {
+				t.Fail()
+			}
+			if b.size != 0 {
+				t.Fail()
+			}
+		case *RingBuffer:
+			if b.buf != nil || b.size != 0 {
+				t.Fail()
+			}
+		}
+	})
 }
 
 //func TestBuffer_CopyToFile(t *testing.T) {
@@ -308,30 +417,32 @@ func TestBuffer_Release(t *testing.T) {
 //}
 //
 func TestBuffer_WriteBytes(t *testing.T) {
-	buf := New()
-	buf.WriteByte(1)
-
-	data := []byte{1, 2, 3, 4}
-	buf.WriteBytes(data)
-
-	if _, err := buf.ReadByte(); err != nil {
-		t.Fail()
-	}
-	d2, err := buf.ReadBytes(4)
-	if err != nil {
-		t.Fail()
-	}
-	if len(d2) != 4 || d2[0] != 1 || d2[1] != 2 || d2[2] != 3 || d2[3] != 4 {
-		t.Fail()
-	}
-
-	if _, err := buf.ReadBytes(1); err != ErrNoEnoughData {
-		t.Fail()
-	}
-
-	if err := buf.WriteBytes(nil); err != nil {
-		t.Fail()
-	}
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{})
+		buf.WriteByte(1)
+
+		data := []byte{1, 2, 3, 4}
+		buf.WriteBytes(data)
+
+		if _, err := buf.ReadByte(); err != nil {
+			t.Fail()
+		}
+		d2, err := buf.ReadBytes(4)
+		if err != nil {
+			t.Fail()
+		}
+		if len(d2) != 4 || d2[0] != 1 || d2[1] != 2 || d2[2] != 3 || d2[3] != 4 {
+			t.Fail()
+		}
+
+		if _, err := buf.ReadBytes(1); err != ErrNoEnoughData {
+			t.Fail()
+		}
+
+		if err := buf.WriteBytes(nil); err != nil {
+			t.Fail()
+		}
+	})
 }
 
 //func TestBuffer_WriteString(t *testing.T) {
@@ -352,118 +463,1006 @@ func TestBuffer_WriteBytes(t *testing.T) {
 //}
 //
 func TestBuffer_Skip(t *testing.T) {
-	buf := New()
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{})
+
+		if err := buf.Skip(1); err != ErrNoEnoughData {
+			t.FailNow()
+		}
+
+		buf.WriteByte(0)
+		buf.WriteUInt32(100)
+
+		if err := buf.Skip(1); err != nil {
+			t.FailNow()
+		}
+
+		if n, err := buf.ReadUInt32(); err != nil || n != 100 {
+			t.FailNow()
+		}
+	})
+}
+
+func Test_BufferFindByte(t *testing.T) {
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{})
+
+		buf.WriteBytes([]byte{1, 2, 3, 4, 5, 6, 7, 8, 9})
+
+		if ind, ok, err := buf.FindByte(0, 5); !ok || err != nil || ind != 4 {
+			t.Fail()
+		}
+
+		if ind, ok, err := buf.FindByte(8, 5); ok || err != nil || ind != -1 {
+			t.Fail()
+		}
+	})
+}
+
+func Test_BufferGetBytes(t *testing.T) {
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{})
+
+		buf.WriteBytes([]byte{1, 2, 3, 4, 5, 6, 7, 8, 9})
+
+		data, err := buf.GetBytes(3, 5)
+		if err != nil {
+			t.Fail()
+		}
+		if data[0] != 4 || data[4] != 8 {
+			t.Fail()
+		}
+	})
+}
+
+func Test_BufferRead(t *testing.T) {
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{})
+
+		buf.WriteBytes([]byte{1, 2, 3, 4, 5, 6, 7, 8, 9})
+
+		data := make([]byte, 4)
+		n, err := buf.Read(data)
+		if err != nil || n != 4 {
+			t.Fail()
+		}
+
+		if data[0] != 1 || data[3] != 4 {
+			t.Fail()
+		}
+	})
+}
+
+func Test_BufferReadToFd(t *testing.T) {
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		var number int64 = 10000
+		buf := newBuf(Options{
+			MinAllocSize: 4,
+		})
+
+		buf.WriteInt64(number)
+
+		path := "/tmp/buffer_test"
+		defer os.Remove(path)
+
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.ModePerm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fd := int(file.Fd())
+
+		n, err := buf.ReadToFd(fd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 8 {
+			t.Fatal()
+		}
+		if buf.Len() > 0 {
+			t.Fatal()
+		}
+		if err = unix.Fsync(fd); err != nil {
+			t.Fatal(err)
+		}
+		if err = unix.Close(fd); err != nil {
+			t.Fatal(err)
+		}
+
+		file, err = os.OpenFile(path, os.O_RDONLY, os.ModePerm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fd = int(file.Fd())
+
+		buf = newBuf(Options{})
+		n, err = buf.WriteFromFd(fd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 8 {
+			t.Fatal()
+		}
+
+		if number, err = buf.ReadInt64(); number != 10000 || err != nil {
+			t.Fatal()
+		}
+	})
+}
+
+func TestBuffer_SetUInt8(t *testing.T) {
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{})
+
+		if err := buf.SetUInt8(0, 1); err != ErrNoEnoughData {
+			t.Fail()
+		}
+
+		buf.WriteUInt16(0)
+		if err := buf.SetUInt8(1, 7); err != nil {
+			t.Fail()
+		}
+		if n, err := buf.ReadUInt16(); err != nil || n != 7 {
+			t.Fail()
+		}
+	})
+}
+
+func TestBuffer_SetUInt32(t *testing.T) {
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{
+			MinAllocSize: 3,
+		})
+
+		buf.WriteUInt32(0)
+		buf.WriteBytes([]byte{1, 2, 3})
+
+		if err := buf.SetUInt32(0, 0xaabbccdd); err != nil {
+			t.Fail()
+		}
+		if err := buf.SetUInt32(4, 1); err != ErrNoEnoughData {
+			t.Fail()
+		}
+		if n, err := buf.ReadUInt32(); err != nil || n != 0xaabbccdd {
+			t.Fail()
+		}
+	})
+}
+
+func TestBuffer_SetUInt64(t *testing.T) {
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{
+			MinAllocSize: 3,
+		})
+
+		buf.WriteByte(0)
+		buf.WriteUInt64(0)
+
+		if err := buf.SetUInt64(1, 0x0102030405060708); err != nil {
+			t.Fail()
+		}
+		if n, err := buf.GetUInt64(1); err != nil || n != 0x0102030405060708 {
+			t.Fail()
+		}
+
+		if err := buf.SetUInt64LE(1, 0x0102030405060708); err != nil {
+			t.Fail()
+		}
+		if n, err := buf.GetUInt64BE(1); err != nil || n != 0x0807060504030201 {
+			t.Fail()
+		}
+	})
+}
+
+func TestBuffer_SetBytes(t *testing.T) {
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{
+			MinAllocSize: 2,
+		})
+
+		for i := 0; i < 6; i++ {
+			buf.WriteByte(0)
+		}
+		buf.ReadByte()
+
+		if err := buf.SetBytes(1, []byte{1, 2, 3}); err != nil {
+			t.Fail()
+		}
+		if err := buf.SetBytes(3, []byte{1, 2, 3}); err != ErrNoEnoughData {
+			t.Fail()
+		}
+
+		data, err := buf.ReadBytes(5)
+		if err != nil {
+			t.Fail()
+		}
+		if data[0] != 0 || data[1] != 1 || data[2] != 2 || data[3] != 3 || data[4] != 0 {
+			t.Fail()
+		}
+	})
+}
+
+func Test_BufferReadToFdManyNodes(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 1,
+	})
 
-	if err := buf.Skip(1); err != ErrNoEnoughData {
-		t.FailNow()
+	size := maxIovecs*2 + 10
+	for i := 0; i < size; i++ {
+		buf.WriteByte(byte(i))
+	}
+	if buf.nc != size {
+		t.Fatal()
 	}
 
-	buf.WriteByte(0)
-	buf.WriteUInt32(100)
+	file, err := os.CreateTemp("", "buffer_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
 
-	if err := buf.Skip(1); err != nil {
-		t.FailNow()
+	n, err := buf.ReadToFd(int(file.Fd()))
+	if err != nil || n != size || buf.Len() != 0 {
+		t.Fatal(n, err)
 	}
 
-	if n, err := buf.ReadUInt32(); err != nil || n != 100 {
-		t.FailNow()
+	data, err := os.ReadFile(file.Name())
+	if err != nil || len(data) != size {
+		t.Fatal(err)
+	}
+	for i, b := range data {
+		if b != byte(i) {
+			t.Fatal(i)
+		}
 	}
 }
 
-func Test_BufferFindByte(t *testing.T) {
-	buf := New()
+func Test_BufferReadToFdPartial(t *testing.T) {
+	var fds [2]int
+	if err := unix.Pipe2(fds[:], unix.O_NONBLOCK); err != nil {
+		t.Fatal(err)
+	}
+	defer unix.Close(fds[0])
+	defer unix.Close(fds[1])
 
-	buf.WriteBytes([]byte{1, 2, 3, 4, 5, 6, 7, 8, 9})
+	buf := NewWithOptions(Options{
+		MinAllocSize: 1000,
+	})
+	size := 1 << 20
+	for i := 0; i < size; i++ {
+		buf.WriteByte(byte(i))
+	}
 
-	if ind, ok, err := buf.FindByte(0, 5); !ok || err != nil || ind != 4 {
+	n, err := buf.ReadToFd(fds[1])
+	if err != nil && err != unix.EAGAIN {
+		t.Fatal(err)
+	}
+	if n <= 0 || n >= size || buf.Len() != size-n {
+		t.Fatal(n)
+	}
+
+	if b, _ := buf.GetByte(0); b != byte(n) {
 		t.Fail()
 	}
 
-	if ind, ok, err := buf.FindByte(8, 5); ok || err != nil || ind != -1 {
+	data := make([]byte, n)
+	if r, err := unix.Read(fds[0], data); err != nil || r != n {
+		t.Fatal(r, err)
+	}
+	for i, b := range data {
+		if b != byte(i) {
+			t.Fatal(i)
+		}
+	}
+}
+
+func Test_BufferWriteFromFdv(t *testing.T) {
+	var fds [2]int
+	if err := unix.Pipe(fds[:]); err != nil {
+		t.Fatal(err)
+	}
+	unix.SetNonblock(fds[0], true)
+	unix.SetNonblock(fds[1], true)
+	defer unix.Close(fds[0])
+	defer unix.Close(fds[1])
+
+	data := make([]byte, 10000)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	if n, err := unix.Write(fds[1], data); err != nil || n != len(data) {
+		t.Fatal(n, err)
+	}
+
+	buf := NewWithOptions(Options{
+		MinAllocSize: 1024,
+	})
+	buf.WriteBytes([]byte{1, 2, 3})
+
+	n, err := buf.WriteFromFdv(fds[0], 8000)
+	if err != nil || n != 8000 {
+		t.Fatal(n, err)
+	}
+	if buf.Len() != 8003 {
+		t.Fatal()
+	}
+
+	n, err = buf.WriteFromFdv(fds[0], 8000)
+	if err != nil || n != 2000 {
+		t.Fatal(n, err)
+	}
+
+	if _, err = buf.WriteFromFdv(fds[0], 8000); err != unix.EAGAIN {
+		t.Fatal(err)
+	}
+	if buf.Len() != 10003 {
+		t.Fatal()
+	}
+
+	buf.Skip(3)
+	got, _ := buf.ReadBytes(10000)
+	for i, b := range got {
+		if b != byte(i) {
+			t.Fatal(i)
+		}
+	}
+}
+
+func Test_BufferWriteFromFdvMaxSize(t *testing.T) {
+	var fds [2]int
+	if err := unix.Pipe(fds[:]); err != nil {
+		t.Fatal(err)
+	}
+	unix.SetNonblock(fds[0], true)
+	unix.SetNonblock(fds[1], true)
+	defer unix.Close(fds[0])
+	defer unix.Close(fds[1])
+
+	unix.Write(fds[1], make([]byte, 100))
+
+	buf := NewWithOptions(Options{
+		MinAllocSize: 16,
+		MaxSize:      40,
+	})
+
+	if n, err := buf.WriteFromFdv(fds[0], 1000); err != nil || n != 40 {
+		t.Fatal(n, err)
+	}
+	if _, err := buf.WriteFromFdv(fds[0], 1000); err != ErrExceedMaximumSize {
+		t.Fatal(err)
+	}
+}
+
+func Test_BufferReadAcrossNodes(t *testing.T) {
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{
+			MinAllocSize: 2,
+		})
+
+		buf.WriteBytes([]byte{1})
+		buf.WriteUInt32(0x02030405)
+
+		data := make([]byte, 10)
+		n, err := buf.Read(data)
+		if err != nil || n != 5 {
+			t.Fatal(n, err)
+		}
+		if !bytes.Equal(data[:n], []byte{1, 2, 3, 4, 5}) {
+			t.Fail()
+		}
+
+		if _, err := buf.Read(data); err != io.EOF {
+			t.Fail()
+		}
+	})
+}
+
+func Test_BufferReadFrom(t *testing.T) {
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		data := make([]byte, 10000)
+		for i := range data {
+			data[i] = byte(i)
+		}
+
+		buf := newBuf(Options{
+			MinAllocSize: 1024,
+		})
+		n, err := buf.ReadFrom(bytes.NewReader(data))
+		if err != nil || n != 10000 || buf.Len() != 10000 {
+			t.Fatal(n, err)
+		}
+
+		got, _ := buf.ReadBytes(10000)
+		if !bytes.Equal(got, data) {
+			t.Fail()
+		}
+
+		buf = newBuf(Options{
+			MinAllocSize: 1024,
+			MaxSize:      3000,
+		})
+		n, err = buf.ReadFrom(bytes.NewReader(data))
+		if err != ErrExceedMaximumSize || n != 3000 || buf.Len() != 3000 {
+			t.Fatal(n, err)
+		}
+	})
+}
+
+func Test_BufferWriteTo(t *testing.T) {
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{
+			MinAllocSize: 16,
+		})
+		for i := 0; i < 100; i++ {
+			buf.WriteByte(byte(i))
+		}
+
+		var out bytes.Buffer
+		n, err := io.Copy(&out, buf)
+		if err != nil || n != 100 || buf.Len() != 0 {
+			t.Fatal(n, err)
+		}
+		for i, b := range out.Bytes() {
+			if b != byte(i) {
+				t.Fatal(i)
+			}
+		}
+	})
+}
+
+func Test_BufferWriteToConn(t *testing.T) {
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{
+			MinAllocSize: 16,
+		})
+		for i := 0; i < 100; i++ {
+			buf.WriteByte(byte(i))
+		}
+
+		c1, c2 := net.Pipe()
+		defer c1.Close()
+
+		done := make(chan []byte)
+		go func() {
+			data, _ := io.ReadAll(c2)
+			done <- data
+		}()
+
+		n, err := buf.WriteTo(c1)
+		if err != nil || n != 100 || buf.Len() != 0 {
+			t.Fatal(n, err)
+		}
+		c1.Close()
+
+		data := <-done
+		if len(data) != 100 || data[99] != 99 {
+			t.Fail()
+		}
+	})
+}
+
+func TestBuffer_UnreadByte(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 1,
+	})
+
+	if err := buf.UnreadByte(); err != ErrInvalidUnreadByte {
+		t.Fail()
+	}
+
+	buf.WriteBytes([]byte{1, 2})
+	if b, err := buf.ReadByte(); err != nil || b != 1 {
+		t.Fail()
+	}
+	if err := buf.UnreadByte(); err != nil {
+		t.Fail()
+	}
+	if err := buf.UnreadByte(); err != ErrInvalidUnreadByte {
+		t.Fail()
+	}
+	if buf.Len() != 2 {
+		t.Fail()
+	}
+
+	buf.ReadByte()
+	buf.ReadByte()
+	if err := buf.UnreadByte(); err != nil {
+		t.Fail()
+	}
+	if b, err := buf.ReadByte(); err != nil || b != 2 {
+		t.Fail()
+	}
+
+	buf.WriteBytes([]byte{3, 4})
+	buf.ReadByte()
+	buf.Skip(1)
+	if err := buf.UnreadByte(); err != ErrInvalidUnreadByte {
 		t.Fail()
 	}
 }
 
-func Test_BufferGetBytes(t *testing.T) {
-	buf := New()
+func TestBuffer_UnreadByteMaxSize(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 8,
+		MaxSize:      4,
+	})
 
-	buf.WriteBytes([]byte{1, 2, 3, 4, 5, 6, 7, 8, 9})
+	buf.WriteUInt32(1)
+	buf.ReadByte()
+	buf.WriteByte(2)
+	if err := buf.UnreadByte(); err != ErrExceedMaximumSize || buf.Len() != 4 {
+		t.Fail()
+	}
 
-	data, err := buf.GetBytes(3, 5)
-	if err != nil {
+	buf.ReadByte()
+	if err := buf.UnreadByte(); err != nil || buf.Len() != 4 {
+		t.Fail()
+	}
+}
+
+func TestBuffer_ReadRune(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 1,
+	})
+
+	if _, _, err := buf.ReadRune(); err != io.EOF {
+		t.Fail()
+	}
+
+	buf.WriteString("a世")
+	buf.WriteBytes([]byte{0xff})
+
+	if r, size, err := buf.ReadRune(); err != nil || r != 'a' || size != 1 {
+		t.Fail()
+	}
+	if r, size, err := buf.ReadRune(); err != nil || r != '世' || size != 3 {
+		t.Fail()
+	}
+	if err := buf.UnreadRune(); err != nil {
+		t.Fail()
+	}
+	if err := buf.UnreadRune(); err != ErrInvalidUnreadRune {
+		t.Fail()
+	}
+	if r, _, err := buf.ReadRune(); err != nil || r != '世' {
+		t.Fail()
+	}
+	if r, size, err := buf.ReadRune(); err != nil || r != utf8.RuneError || size != 1 {
+		t.Fail()
+	}
+
+	buf.WriteString("世"[:2])
+	if _, _, err := buf.ReadRune(); err != ErrNoEnoughData {
 		t.Fail()
 	}
-	if data[0] != 4 || data[4] != 8 {
+	buf.WriteString("世"[2:])
+	if r, _, err := buf.ReadRune(); err != nil || r != '世' {
+		t.Fail()
+	}
+
+	buf.WriteByte('x')
+	buf.ReadByte()
+	if err := buf.UnreadRune(); err != ErrInvalidUnreadRune {
 		t.Fail()
 	}
 }
 
-func Test_BufferRead(t *testing.T) {
+func TestBuffer_RuneScanner(t *testing.T) {
 	buf := New()
+	buf.WriteString("12 hello 3.5")
 
-	buf.WriteBytes([]byte{1, 2, 3, 4, 5, 6, 7, 8, 9})
+	var n int
+	var s string
+	var f float64
+	if c, err := fmt.Fscan(buf, &n, &s, &f); err != nil || c != 3 {
+		t.Fatal(c, err)
+	}
+	if n != 12 || s != "hello" || f != 3.5 {
+		t.Fail()
+	}
+
+	buf.WriteString("foo123")
+	if ok, err := regexp.MatchReader(`^foo\d+$`, buf); err != nil || !ok {
+		t.Fail()
+	}
+}
+
+func TestBuffer_ReadLine(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 3,
+		MaxLineSize:  8,
+	})
 
-	data := make([]byte, 4)
-	n, err := buf.Read(data)
-	if err != nil || n != 4 {
+	buf.WriteString("hello\r")
+	if _, err := buf.ReadLine(); err != ErrNoEnoughData {
+		t.Fail()
+	}
+	if buf.Len() != 6 {
 		t.Fail()
 	}
 
-	if data[0] != 1 || data[3] != 4 {
+	buf.WriteString("\nworld\n\n")
+	if line, err := buf.ReadLine(); err != nil || string(line) != "hello" {
+		t.Fail()
+	}
+	if line, err := buf.ReadLine(); err != nil || string(line) != "world" {
+		t.Fail()
+	}
+	if line, err := buf.ReadLine(); err != nil || len(line) != 0 {
+		t.Fail()
+	}
+
+	buf.WriteString("123456789")
+	if _, err := buf.ReadLine(); err != ErrLineTooLong {
+		t.Fail()
+	}
+	buf.WriteString("\n")
+	if _, err := buf.ReadLine(); err != ErrLineTooLong {
+		t.Fail()
+	}
+	if buf.Len() != 10 {
+		t.Fail()
+	}
+
+	buf.Skip(10)
+	buf.WriteString("12345678\r")
+	if _, err := buf.ReadLine(); err != ErrNoEnoughData {
+		t.Fail()
+	}
+	buf.WriteString("\n")
+	if line, err := buf.ReadLine(); err != nil || string(line) != "12345678" {
 		t.Fail()
 	}
 }
 
-func Test_BufferReadToFd(t *testing.T) {
-	var number int64 = 10000
+func TestBuffer_ReadUntil(t *testing.T) {
 	buf := NewWithOptions(Options{
-		MinAllocSize: 4,
+		MinAllocSize: 2,
 	})
 
-	buf.WriteInt64(number)
+	buf.WriteString("key=value;")
+	if data, err := buf.ReadUntil('='); err != nil || string(data) != "key=" {
+		t.Fail()
+	}
+	if _, err := buf.ReadUntil('&'); err != ErrNoEnoughData {
+		t.Fail()
+	}
+	if data, err := buf.ReadUntil(';'); err != nil || string(data) != "value;" {
+		t.Fail()
+	}
+}
 
-	path := "/tmp/buffer_test"
-	defer os.Remove(path)
+func TestBuffer_ReadUntilBytes(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 3,
+	})
 
-	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.ModePerm)
-	if err != nil {
-		t.Fatal(err)
+	buf.WriteString("GET / HTTP/1.1\r\nHost: a\r\n\r")
+	if _, err := buf.ReadUntilBytes([]byte("\r\n\r\n")); err != ErrNoEnoughData {
+		t.Fail()
+	}
+	if buf.Len() != 26 {
+		t.Fail()
 	}
-	fd := int(file.Fd())
 
-	n, err := buf.ReadToFd(fd)
-	if err != nil {
+	buf.WriteString("\nbody")
+	data, err := buf.ReadUntilBytes([]byte("\r\n\r\n"))
+	if err != nil || string(data) != "GET / HTTP/1.1\r\nHost: a\r\n\r\n" {
+		t.Fail()
+	}
+	if buf.Len() != 4 {
+		t.Fail()
+	}
+}
+
+func TestBuffer_Reserve(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 8,
+	})
+
+	buf.WriteString("n=")
+	p, err := buf.Reserve(4)
+	if err != nil || len(p) != 4 {
 		t.Fatal(err)
 	}
-	if n != 8 {
-		t.Fatal()
+	p = strconv.AppendInt(p[:0], 12345, 10)
+	if err := buf.Commit(len(p)); err != nil {
+		t.Fail()
 	}
-	if buf.Len() > 0 {
-		t.Fatal()
+
+	p, _ = buf.Reserve(hex.EncodedLen(2))
+	hex.Encode(p, []byte{0xab, 0xcd})
+	buf.Commit(len(p))
+
+	if s, err := buf.ReadString(buf.Len()); err != nil || s != "n=12345abcd" {
+		t.Fail()
 	}
-	if err = unix.Fsync(fd); err != nil {
-		t.Fatal(err)
+}
+
+func TestBuffer_ReserveMaxSize(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 16,
+		MaxSize:      10,
+	})
+
+	if _, err := buf.Reserve(11); err != ErrExceedMaximumSize {
+		t.Fail()
 	}
-	if err = unix.Close(fd); err != nil {
+
+	p, err := buf.Reserve(4)
+	if err != nil || cap(p) != 10 {
 		t.Fatal(err)
 	}
+	if err := buf.Commit(4); err != nil || buf.Len() != 4 {
+		t.Fail()
+	}
 
-	file, err = os.OpenFile(path, os.O_RDONLY, os.ModePerm)
-	if err != nil {
-		t.Fatal(err)
+	defer func() {
+		if recover() == nil {
+			t.Fail()
+		}
+	}()
+	buf.Commit(13)
+}
+
+func TestBuffer_Prepend(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 16,
+		Headroom:     8,
+	})
+
+	buf.WriteString("body")
+	if err := buf.PrependUInt32(4); err != nil {
+		t.Fail()
+	}
+	if err := buf.Prepend([]byte{1, 2, 3, 4, 5}); err != nil {
+		t.Fail()
+	}
+	if buf.nc != 2 {
+		t.Fatal(buf.nc)
 	}
-	fd = int(file.Fd())
 
-	buf = New()
-	n, err = buf.WriteFromFd(fd)
-	if err != nil {
-		t.Fatal(err)
+	if data, err := buf.ReadBytes(5); err != nil || !bytes.Equal(data, []byte{1, 2, 3, 4, 5}) {
+		t.Fail()
 	}
-	if n != 8 {
-		t.Fatal()
+	if n, err := buf.ReadUInt32(); err != nil || n != 4 {
+		t.Fail()
+	}
+	if s, err := buf.ReadString(4); err != nil || s != "body" {
+		t.Fail()
 	}
+}
 
-	if number, err = buf.ReadInt64(); number != 10000 || err != nil {
-		t.Fatal()
+func TestBuffer_PrependWithoutHeadroom(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 4,
+	})
+
+	if err := buf.PrependUInt16LE(0x0102); err != nil {
+		t.Fail()
+	}
+	buf.WriteByte(3)
+	buf.PrependUInt64(5)
+	buf.PrependUInt16(6)
+
+	if n, err := buf.ReadUInt16(); err != nil || n != 6 {
+		t.Fail()
+	}
+	if n, err := buf.ReadUInt64(); err != nil || n != 5 {
+		t.Fail()
+	}
+	if n, err := buf.ReadUInt16LE(); err != nil || n != 0x0102 {
+		t.Fail()
+	}
+	if n, err := buf.ReadByte(); err != nil || n != 3 {
+		t.Fail()
+	}
+}
+
+func TestBuffer_PrependErrors(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 4,
+		MaxSize:      4,
+	})
+	buf.WriteUInt16(1)
+
+	if err := buf.PrependUInt32(1); err != ErrExceedMaximumSize {
+		t.Fail()
+	}
+
+	buf.Mark()
+	if err := buf.PrependUInt16(1); err != ErrMarked {
+		t.Fail()
+	}
+}
+
+func TestBuffer_PrependFrame(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 16,
+		Headroom:     4,
+	})
+
+	buf.WriteString("abc")
+	f, _ := buf.BeginFrame(1)
+	buf.WriteString("de")
+	buf.Skip(3)
+	buf.PrependUInt16(0xffff)
+
+	if err := buf.EndFrame(f); err != nil {
+		t.Fail()
+	}
+	if n, err := buf.GetUInt8(2); err != nil || n != 2 {
+		t.Fail()
+	}
+}
+
+func TestBuffer_NodeRing(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 4,
+	})
+
+	for i := 0; i < 1000; i++ {
+		buf.WriteUInt32(uint32(i))
+		if i >= 3 {
+			if n, err := buf.ReadUInt32(); err != nil || n != uint32(i-3) {
+				t.Fatal(i, n)
+			}
+		}
+		if n, err := buf.GetUInt32(8); i >= 3 && (err != nil || n != uint32(i)) {
+			t.Fatal(i, n)
+		}
+	}
+	if len(buf.nodes) > 4 || buf.head == 0 {
+		t.Fatal(len(buf.nodes), buf.head)
+	}
+}
+
+func TestBuffer_NodeRingRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	buf := NewWithOptions(Options{
+		MinAllocSize: 3,
+	})
+	var model []byte
+	var consumed []byte //bytes skipped since Mark
+
+	for n := 0; n < 5000; n++ {
+		switch r.Intn(8) {
+		case 0, 1:
+			p := make([]byte, r.Intn(8))
+			r.Read(p)
+			buf.WriteBytes(p)
+			model = append(model, p...)
+		case 2:
+			l := r.Intn(len(model) + 1)
+			buf.Skip(l)
+			if buf.marked {
+				consumed = append(consumed, model[:l]...)
+			}
+			model = model[l:]
+		case 3:
+			if buf.marked {
+				continue
+			}
+			p := make([]byte, r.Intn(4))
+			r.Read(p)
+			buf.Prepend(p)
+			model = append(p, model...)
+		case 4:
+			if buf.marked {
+				buf.ResetToMark()
+				buf.DiscardMark()
+				model = append(consumed, model...)
+				consumed = nil
+			} else {
+				buf.Mark()
+			}
+		case 5:
+			if len(model) == 0 {
+				continue
+			}
+			idx := r.Intn(len(model))
+			if b, err := buf.GetByte(idx); err != nil || b != model[idx] {
+				t.Fatal(n, idx)
+			}
+		case 6:
+			idx := r.Intn(len(model) + 1)
+			p := make([]byte, r.Intn(4))
+			r.Read(p)
+			if err := buf.Insert(idx, p); err != nil {
+				t.Fatal(n, err)
+			}
+			model = append(model[:idx], append(p, model[idx:]...)...)
+		case 7:
+			idx := r.Intn(len(model) + 1)
+			l := r.Intn(len(model) - idx + 1)
+			if err := buf.Delete(idx, l); err != nil {
+				t.Fatal(n, err)
+			}
+			model = append(model[:idx], model[idx+l:]...)
+		}
+
+		if buf.Len() != len(model) {
+			t.Fatal(n, buf.Len(), len(model))
+		}
+		if data, _ := buf.GetBytes(0, buf.Len()); !bytes.Equal(data, model) {
+			t.Fatal(n)
+		}
+	}
+}
+
+func TestBuffer_RetainSize(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 8,
+		RetainSize:   16,
+	})
+
+	buf.WriteUInt64(1)
+	buf.WriteUInt64(2)
+	buf.WriteUInt64(3)
+	p := &buf.nodeAt(0).buf[0]
+	buf.Skip(24)
+	if buf.nc != 0 || len(buf.spare) != 2 || buf.spareSize != 16 {
+		t.Fatal(buf.nc, len(buf.spare), buf.spareSize)
+	}
+
+	for i := 0; i < 100; i++ {
+		buf.WriteUInt32(uint32(i))
+		if n, err := buf.ReadUInt32(); err != nil || n != uint32(i) {
+			t.Fatal(i, n)
+		}
+		if len(buf.spare) != 2 {
+			t.Fatal(i, len(buf.spare))
+		}
+	}
+
+	buf.WriteUInt64(1)
+	buf.WriteUInt64(2)
+	if &buf.nodeAt(0).buf[0] != p && &buf.nodeAt(1).buf[0] != p {
+		t.Fail()
+	}
+	if n, _ := buf.GetUInt64(8); n != 2 {
+		t.Fail()
+	}
+
+	buf.Skip(16)
+	buf.Trim()
+	if len(buf.spare) != 0 || buf.spareSize != 0 {
+		t.Fail()
+	}
+}
+
+func TestBuffer_RetainShared(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 8,
+		RetainSize:   64,
+		Headroom:     2,
+	})
+
+	buf.WriteUInt32(1)
+	dup := buf.Duplicate()
+	buf.Skip(4)
+	if len(buf.spare) != 0 {
+		t.Fail()
+	}
+	dup.Release()
+
+	buf.WriteUInt32(2)
+	buf.Skip(4)
+	if len(buf.spare) != 1 || buf.spareSize != buf.spare[0].Cap() {
+		t.Fatal(len(buf.spare), buf.spareSize)
+	}
+
+	buf.WriteUInt32(3)
+	if len(buf.spare) != 0 {
+		t.Fail()
+	}
+	if err := buf.PrependUInt16(0xffff); err != nil || buf.nc != 1 {
+		t.Fail()
+	}
+
+	buf.Release()
+	if len(buf.spare) != 0 {
+		t.Fail()
 	}
 }
@@ -0,0 +1,100 @@
+package buffer
+
+// Insert inserts p before the idx-th readable byte. The node holding idx is split in
+// two rather than shifting its data, so only the part after idx is moved.
+func (t *Buffer) Insert(idx int, p []byte) error {
+	if err := t.ensureReadable(idx); err != nil {
+		return err
+	}
+	if err := t.ensureWriteable(len(p)); err != nil {
+		return err
+	}
+	if len(p) == 0 {
+		return nil
+	}
+	if idx == t.size {
+		return t.WriteBytes(p)
+	}
+
+	data := t.spareNode(len(p))
+	data.w = copy(data.buf, p)
+
+	i, ni := t.getNode(idx)
+	if no := t.nodeAt(i); ni == no.r && (!t.marked || no.r == no.h) {
+		no.h = no.r
+		t.insertAt(i, data)
+	} else {
+		t.insertAt(i+1, data, t.splitNode(no, ni))
+	}
+
+	t.size += len(p)
+	t.lastSize = 0
+	t.edits++
+	t.adjust(i)
+	return nil
+}
+
+// Delete removes n readable bytes starting from idx. Nodes are truncated, dropped or
+// split, but their data is never shifted.
+func (t *Buffer) Delete(idx int, n int) error {
+	if err := t.ensureReadable(idx); err != nil {
+		return err
+	}
+	if err := t.ensureReadable(idx + n); err != nil {
+		return err
+	}
+	if n == 0 {
+		return nil
+	}
+
+	t.size -= n
+	t.lastSize = 0
+	t.edits++
+
+	i, ni := t.getNode(idx)
+	no := t.nodeAt(i)
+	keep := t.marked && no.r > no.h //consumed bytes held for the mark
+	if ni+n < no.w {
+		if ni == no.r && !keep {
+			no.r += n
+			no.h = no.r
+		} else {
+			rest := t.splitNode(no, ni+n)
+			no.w = ni
+			t.insertAt(i+1, rest)
+		}
+
+		t.adjust(i)
+		return nil
+	}
+
+	n -= no.w - ni
+	no.w = ni
+
+	j := i + 1
+	for n > 0 && n >= t.nodeAt(j).ReadableBytes() {
+		n -= t.nodeAt(j).ReadableBytes()
+		j++
+	}
+	if n > 0 {
+		last := t.nodeAt(j)
+		last.r += n
+		last.h = last.r
+	}
+
+	if no.ReadableBytes() == 0 && !keep {
+		t.removeAt(i, j-i)
+	} else {
+		t.removeAt(i+1, j-i-1)
+	}
+
+	t.adjust(i)
+	return nil
+}
+
+// splitNode cuts no at the buffer index at, returning a node sharing the data after it.
+func (t *Buffer) splitNode(no *node, at int) *node {
+	rest := no.share(at, no.w)
+	no.w = at
+	return rest
+}
@@ -0,0 +1,152 @@
+package buffer
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestBuffer_Insert(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 8,
+	})
+	buf.WriteString("GET / HTTP/1.1\r\n")
+
+	if err := buf.Insert(5, []byte("index.html")); err != nil {
+		t.Fail()
+	}
+	if err := buf.Insert(0, []byte(">")); err != nil {
+		t.Fail()
+	}
+	if err := buf.Insert(buf.Len(), []byte("<")); err != nil {
+		t.Fail()
+	}
+	if err := buf.Insert(buf.Len()+1, []byte("x")); err != ErrNoEnoughData {
+		t.Fail()
+	}
+
+	if s, _ := buf.ReadString(buf.Len()); s != ">GET /index.html HTTP/1.1\r\n<" {
+		t.Fail()
+	}
+
+	buf = NewWithOptions(Options{
+		MinAllocSize: 8,
+		MaxSize:      4,
+	})
+	buf.WriteUInt16(0)
+	if err := buf.Insert(1, []byte{1, 2, 3}); err != ErrExceedMaximumSize {
+		t.Fail()
+	}
+}
+
+func TestBuffer_Delete(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 4,
+	})
+	for _, c := range []byte("0123456789abcdef") {
+		buf.WriteByte(c)
+	}
+
+	if err := buf.Delete(1, 2); err != nil {
+		t.Fail()
+	}
+	if err := buf.Delete(0, 1); err != nil {
+		t.Fail()
+	}
+	if err := buf.Delete(2, 7); err != nil {
+		t.Fail()
+	}
+	if err := buf.Delete(4, 3); err != ErrNoEnoughData {
+		t.Fail()
+	}
+
+	if s, _ := buf.ReadString(buf.Len()); s != "34cdef" {
+		t.Fail()
+	}
+}
+
+func TestBuffer_InsertDeleteRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	buf := NewWithOptions(Options{
+		MinAllocSize: 4,
+	})
+	var model []byte
+
+	for n := 0; n < 2000; n++ {
+		switch r.Intn(4) {
+		case 0:
+			p := make([]byte, r.Intn(6))
+			r.Read(p)
+			idx := r.Intn(len(model) + 1)
+			buf.Insert(idx, p)
+			model = append(model[:idx], append(append([]byte(nil), p...), model[idx:]...)...)
+		case 1:
+			if len(model) == 0 {
+				continue
+			}
+			idx := r.Intn(len(model))
+			l := r.Intn(len(model) - idx + 1)
+			buf.Delete(idx, l)
+			model = append(model[:idx], model[idx+l:]...)
+		case 2:
+			b := byte(r.Intn(256))
+			buf.WriteByte(b)
+			model = append(model, b)
+		case 3:
+			if len(model) > 0 {
+				buf.Skip(1)
+				model = model[1:]
+			}
+		}
+
+		if buf.Len() != len(model) {
+			t.Fatal(n, buf.Len(), len(model))
+		}
+		if data, _ := buf.GetBytes(0, buf.Len()); !bytes.Equal(data, model) {
+			t.Fatal(n)
+		}
+	}
+}
+
+func TestBuffer_DeleteMarked(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 4,
+	})
+	for _, c := range []byte("01234567") {
+		buf.WriteByte(c)
+	}
+
+	buf.Mark()
+	buf.Skip(2)
+	buf.Delete(0, 3)
+	buf.Insert(0, []byte("x"))
+	if s, _ := buf.GetBytes(0, buf.Len()); string(s) != "x567" {
+		t.Fatal(string(s))
+	}
+
+	buf.ResetToMark()
+	if s, _ := buf.ReadString(buf.Len()); s != "01x567" {
+		t.Fatal(s)
+	}
+}
+
+func TestBuffer_InsertRetained(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 8,
+		RetainSize:   16,
+	})
+	buf.WriteUInt64(1)
+	buf.WriteUInt64(1)
+	buf.Skip(16)
+
+	buf.WriteUInt64(2)
+	spare := buf.spare[0]
+	buf.Insert(4, []byte("ab"))
+	if len(buf.spare) != 0 || buf.nodeAt(1) != spare {
+		t.Fail()
+	}
+	if s, _ := buf.GetBytes(4, 2); string(s) != "ab" {
+		t.Fail()
+	}
+}
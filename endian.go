@@ -0,0 +1,351 @@
+package buffer
+
+import "encoding/binary"
+
+//#region read logic
+
+func (t *accessor) GetUInt16LE(idx int) (uint16, error) {
+	if err := t.ensureReadable(idx + 2); err != nil {
+		return 0, err
+	}
+
+	return t.getUInt16Order(idx, binary.LittleEndian), nil
+}
+
+func (t *accessor) GetInt16LE(idx int) (int16, error) {
+	res, err := t.GetUInt16LE(idx)
+	return int16(res), err
+}
+
+func (t *accessor) GetUInt32LE(idx int) (uint32, error) {
+	if err := t.ensureReadable(idx + 4); err != nil {
+		return 0, err
+	}
+
+	return t.getUInt32Order(idx, binary.LittleEndian), nil
+}
+
+func (t *accessor) GetInt32LE(idx int) (int32, error) {
+	res, err := t.GetUInt32LE(idx)
+	return int32(res), err
+}
+
+func (t *accessor) GetUInt64LE(idx int) (uint64, error) {
+	if err := t.ensureReadable(idx + 8); err != nil {
+		return 0, err
+	}
+
+	return t.getUInt64Order(idx, binary.LittleEndian), nil
+}
+
+func (t *accessor) GetInt64LE(idx int) (int64, error) {
+	res, err := t.GetUInt64LE(idx)
+	return int64(res), err
+}
+
+func (t *accessor) GetUInt16BE(idx int) (uint16, error) {
+	if err := t.ensureReadable(idx + 2); err != nil {
+		return 0, err
+	}
+
+	return t.getUInt16Order(idx, binary.BigEndian), nil
+}
+
+func (t *accessor) GetInt16BE(idx int) (int16, error) {
+	res, err := t.GetUInt16BE(idx)
+	return int16(res), err
+}
+
+func (t *accessor) GetUInt32BE(idx int) (uint32, error) {
+	if err := t.ensureReadable(idx + 4); err != nil {
+		return 0, err
+	}
+
+	return t.getUInt32Order(idx, binary.BigEndian), nil
+}
+
+func (t *accessor) GetInt32BE(idx int) (int32, error) {
+	res, err := t.GetUInt32BE(idx)
+	return int32(res), err
+}
+
+func (t *accessor) GetUInt64BE(idx int) (uint64, error) {
+	if err := t.ensureReadable(idx + 8); err != nil {
+		return 0, err
+	}
+
+	return t.getUInt64Order(idx, binary.BigEndian), nil
+}
+
+func (t *accessor) GetInt64BE(idx int) (int64, error) {
+	res, err := t.GetUInt64BE(idx)
+	return int64(res), err
+}
+
+func (t *accessor) ReadUInt16LE() (uint16, error) {
+	n, err := t.GetUInt16LE(0)
+	if err == nil {
+		t.skip(2)
+	}
+	return n, err
+}
+
+func (t *accessor) ReadInt16LE() (int16, error) {
+	res, err := t.ReadUInt16LE()
+	return int16(res), err
+}
+
+func (t *accessor) ReadUInt32LE() (uint32, error) {
+	n, err := t.GetUInt32LE(0)
+	if err == nil {
+		t.skip(4)
+	}
+	return n, err
+}
+
+func (t *accessor) ReadInt32LE() (int32, error) {
+	res, err := t.ReadUInt32LE()
+	return int32(res), err
+}
+
+func (t *accessor) ReadUInt64LE() (uint64, error) {
+	n, err := t.GetUInt64LE(0)
+	if err == nil {
+		t.skip(8)
+	}
+	return n, err
+}
+
+func (t *accessor) ReadInt64LE() (int64, error) {
+	res, err := t.ReadUInt64LE()
+	return int64(res), err
+}
+
+func (t *accessor) ReadUInt16BE() (uint16, error) {
+	n, err := t.GetUInt16BE(0)
+	if err == nil {
+		t.skip(2)
+	}
+	return n, err
+}
+
+func (t *accessor) ReadInt16BE() (int16, error) {
+	res, err := t.ReadUInt16BE()
+	return int16(res), err
+}
+
+func (t *accessor) ReadUInt32BE() (uint32, error) {
+	n, err := t.GetUInt32BE(0)
+	if err == nil {
+		t.skip(4)
+	}
+	return n, err
+}
+
+func (t *accessor) ReadInt32BE() (int32, error) {
+	res, err := t.ReadUInt32BE()
+	return int32(res), err
+}
+
+func (t *accessor) ReadUInt64BE() (uint64, error) {
+	n, err := t.GetUInt64BE(0)
+	if err == nil {
+		t.skip(8)
+	}
+	return n, err
+}
+
+func (t *accessor) ReadInt64BE() (int64, error) {
+	res, err := t.ReadUInt64BE()
+	return int64(res), err
+}
+
+//#endregion
+
+//#region write logic
+
+func (t *accessor) WriteUInt16LE(n uint16) error {
+	if err := t.ensureWriteable(2); err != nil {
+		return err
+	}
+
+	t.writeUInt16Order(n, binary.LittleEndian)
+	return nil
+}
+
+func (t *accessor) WriteInt16LE(n int16) error {
+	return t.WriteUInt16LE(uint16(n))
+}
+
+func (t *accessor) WriteUInt32LE(n uint32) error {
+	if err := t.ensureWriteable(4); err != nil {
+		return err
+	}
+
+	t.writeUInt32Order(n, binary.LittleEndian)
+	return nil
+}
+
+func (t *accessor) WriteInt32LE(n int32) error {
+	return t.WriteUInt32LE(uint32(n))
+}
+
+func (t *accessor) WriteUInt64LE(n uint64) error {
+	if err := t.ensureWriteable(8); err != nil {
+		return err
+	}
+
+	t.writeUInt64Order(n, binary.LittleEndian)
+	return nil
+}
+
+func (t *accessor) WriteInt64LE(n int64) error {
+	return t.WriteUInt64LE(uint64(n))
+}
+
+func (t *accessor) WriteUInt16BE(n uint16) error {
+	if err := t.ensureWriteable(2); err != nil {
+		return err
+	}
+
+	t.writeUInt16Order(n, binary.BigEndian)
+	return nil
+}
+
+func (t *accessor) WriteInt16BE(n int16) error {
+	return t.WriteUInt16BE(uint16(n))
+}
+
+func (t *accessor) WriteUInt32BE(n uint32) error {
+	if err := t.ensureWriteable(4); err != nil {
+		return err
+	}
+
+	t.writeUInt32Order(n, binary.BigEndian)
+	return nil
+}
+
+func (t *accessor) WriteInt32BE(n int32) error {
+	return t.WriteUInt32BE(uint32(n))
+}
+
+func (t *accessor) WriteUInt64BE(n uint64) error {
+	if err := t.ensureWriteable(8); err != nil {
+		return err
+	}
+
+	t.writeUInt64Order(n, binary.BigEndian)
+	return nil
+}
+
+func (t *accessor) WriteInt64BE(n int64) error {
+	return t.WriteUInt64BE(uint64(n))
+}
+
+func (t *Buffer) PrependUInt16LE(n uint16) error {
+	return t.prependUInt16Order(n, binary.LittleEndian)
+}
+
+func (t *Buffer) PrependUInt32LE(n uint32) error {
+	return t.prependUInt32Order(n, binary.LittleEndian)
+}
+
+func (t *Buffer) PrependUInt64LE(n uint64) error {
+	return t.prependUInt64Order(n, binary.LittleEndian)
+}
+
+func (t *Buffer) PrependUInt16BE(n uint16) error {
+	return t.prependUInt16Order(n, binary.BigEndian)
+}
+
+func (t *Buffer) PrependUInt32BE(n uint32) error {
+	return t.prependUInt32Order(n, binary.BigEndian)
+}
+
+func (t *Buffer) PrependUInt64BE(n uint64) error {
+	return t.prependUInt64Order(n, binary.BigEndian)
+}
+
+//#endregion
+
+//#region set logic
+
+func (t *accessor) SetUInt16LE(idx int, n uint16) error {
+	if err := t.ensureReadable(idx + 2); err != nil {
+		return err
+	}
+
+	t.setUInt16Order(idx, n, binary.LittleEndian)
+	return nil
+}
+
+func (t *accessor) SetInt16LE(idx int, n int16) error {
+	return t.SetUInt16LE(idx, uint16(n))
+}
+
+func (t *accessor) SetUInt32LE(idx int, n uint32) error {
+	if err := t.ensureReadable(idx + 4); err != nil {
+		return err
+	}
+
+	t.setUInt32Order(idx, n, binary.LittleEndian)
+	return nil
+}
+
+func (t *accessor) SetInt32LE(idx int, n int32) error {
+	return t.SetUInt32LE(idx, uint32(n))
+}
+
+func (t *accessor) SetUInt64LE(idx int, n uint64) error {
+	if err := t.ensureReadable(idx + 8); err != nil {
+		return err
+	}
+
+	t.setUInt64Order(idx, n, binary.LittleEndian)
+	return nil
+}
+
+func (t *accessor) SetInt64LE(idx int, n int64) error {
+	return t.SetUInt64LE(idx, uint64(n))
+}
+
+func (t *accessor) SetUInt16BE(idx int, n uint16) error {
+	if err := t.ensureReadable(idx + 2); err != nil {
+		return err
+	}
+
+	t.setUInt16Order(idx, n, binary.BigEndian)
+	return nil
+}
+
+func (t *accessor) SetInt16BE(idx int, n int16) error {
+	return t.SetUInt16BE(idx, uint16(n))
+}
+
+func (t *accessor) SetUInt32BE(idx int, n uint32) error {
+	if err := t.ensureReadable(idx + 4); err != nil {
+		return err
+	}
+
+	t.setUInt32Order(idx, n, binary.BigEndian)
+	return nil
+}
+
+func (t *accessor) SetInt32BE(idx int, n int32) error {
+	return t.SetUInt32BE(idx, uint32(n))
+}
+
+func (t *accessor) SetUInt64BE(idx int, n uint64) error {
+	if err := t.ensureReadable(idx + 8); err != nil {
+		return err
+	}
+
+	t.setUInt64Order(idx, n, binary.BigEndian)
+	return nil
+}
+
+func (t *accessor) SetInt64BE(idx int, n int64) error {
+	return t.SetUInt64BE(idx, uint64(n))
+}
+
+//#endregion
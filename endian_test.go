@@ -0,0 +1,126 @@
+package buffer
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestBuffer_WriteUInt16LE(t *testing.T) {
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{})
+
+		if err := buf.WriteUInt16LE(0x0102); err != nil {
+			t.Fail()
+		}
+
+		if b, _ := buf.GetByte(0); b != 0x02 {
+			t.Fail()
+		}
+
+		if n, err := buf.ReadUInt16LE(); err != nil || n != 0x0102 {
+			t.Fail()
+		}
+
+		if _, err := buf.ReadUInt16LE(); err != ErrNoEnoughData {
+			t.Fail()
+		}
+	})
+}
+
+func TestBuffer_WriteUInt32LE(t *testing.T) {
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{})
+
+		if err := buf.WriteUInt32LE(0x01020304); err != nil {
+			t.Fail()
+		}
+
+		if b, _ := buf.GetByte(0); b != 0x04 {
+			t.Fail()
+		}
+
+		if n, err := buf.ReadUInt32LE(); err != nil || n != 0x01020304 {
+			t.Fail()
+		}
+
+		if _, err := buf.ReadUInt32LE(); err != ErrNoEnoughData {
+			t.Fail()
+		}
+	})
+}
+
+func TestBuffer_WriteInt64LE(t *testing.T) {
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{})
+
+		if err := buf.WriteInt64LE(-2); err != nil {
+			t.Fail()
+		}
+
+		if n, err := buf.GetUInt64BE(0); err != nil || n != 0xfeffffffffffffff {
+			t.Fail()
+		}
+
+		if n, err := buf.ReadInt64LE(); err != nil || n != -2 {
+			t.Fail()
+		}
+
+		if _, err := buf.ReadInt64LE(); err != ErrNoEnoughData {
+			t.Fail()
+		}
+	})
+}
+
+func TestBuffer_LEAcrossNodes(t *testing.T) {
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{
+			MinAllocSize: 3,
+		})
+
+		buf.WriteUInt8(0)
+		buf.WriteUInt64LE(0x0102030405060708)
+		buf.WriteUInt32LE(0x090a0b0c)
+		buf.WriteUInt16LE(0x0d0e)
+
+		if b, ok := buf.ByteBuffer.(*Buffer); ok && b.nc < 2 {
+			t.Fatal()
+		}
+
+		if n, err := buf.GetUInt64LE(1); err != nil || n != 0x0102030405060708 {
+			t.Fail()
+		}
+		if n, err := buf.GetUInt32LE(9); err != nil || n != 0x090a0b0c {
+			t.Fail()
+		}
+		if n, err := buf.GetUInt16LE(13); err != nil || n != 0x0d0e {
+			t.Fail()
+		}
+		if _, err := buf.GetUInt16LE(14); err != ErrNoEnoughData {
+			t.Fail()
+		}
+	})
+}
+
+func TestBuffer_ByteOrderOption(t *testing.T) {
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{
+			MinAllocSize: 2048,
+			ByteOrder:    binary.LittleEndian,
+		})
+
+		buf.WriteUInt32(0x01020304)
+		buf.WriteUInt16BE(0x0506)
+
+		if n, err := buf.GetUInt32LE(0); err != nil || n != 0x01020304 {
+			t.Fail()
+		}
+
+		if n, err := buf.ReadUInt32(); err != nil || n != 0x01020304 {
+			t.Fail()
+		}
+
+		if n, err := buf.ReadUInt16BE(); err != nil || n != 0x0506 {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,95 @@
+package buffer
+
+import "math"
+
+//#region read logic
+
+func (t *accessor) GetFloat32(idx int) (float32, error) {
+	res, err := t.GetUInt32(idx)
+	return math.Float32frombits(res), err
+}
+
+func (t *accessor) GetFloat64(idx int) (float64, error) {
+	res, err := t.GetUInt64(idx)
+	return math.Float64frombits(res), err
+}
+
+func (t *accessor) GetFloat32LE(idx int) (float32, error) {
+	res, err := t.GetUInt32LE(idx)
+	return math.Float32frombits(res), err
+}
+
+func (t *accessor) GetFloat64LE(idx int) (float64, error) {
+	res, err := t.GetUInt64LE(idx)
+	return math.Float64frombits(res), err
+}
+
+func (t *accessor) GetFloat32BE(idx int) (float32, error) {
+	res, err := t.GetUInt32BE(idx)
+	return math.Float32frombits(res), err
+}
+
+func (t *accessor) GetFloat64BE(idx int) (float64, error) {
+	res, err := t.GetUInt64BE(idx)
+	return math.Float64frombits(res), err
+}
+
+func (t *accessor) ReadFloat32() (float32, error) {
+	res, err := t.ReadUInt32()
+	return math.Float32frombits(res), err
+}
+
+func (t *accessor) ReadFloat64() (float64, error) {
+	res, err := t.ReadUInt64()
+	return math.Float64frombits(res), err
+}
+
+func (t *accessor) ReadFloat32LE() (float32, error) {
+	res, err := t.ReadUInt32LE()
+	return math.Float32frombits(res), err
+}
+
+func (t *accessor) ReadFloat64LE() (float64, error) {
+	res, err := t.ReadUInt64LE()
+	return math.Float64frombits(res), err
+}
+
+func (t *accessor) ReadFloat32BE() (float32, error) {
+	res, err := t.ReadUInt32BE()
+	return math.Float32frombits(res), err
+}
+
+func (t *accessor) ReadFloat64BE() (float64, error) {
+	res, err := t.ReadUInt64BE()
+	return math.Float64frombits(res), err
+}
+
+//#endregion
+
+//#region write logic
+
+func (t *accessor) WriteFloat32(n float32) error {
+	return t.WriteUInt32(math.Float32bits(n))
+}
+
+func (t *accessor) WriteFloat64(n float64) error {
+	return t.WriteUInt64(math.Float64bits(n))
+}
+
+func (t *accessor) WriteFloat32LE(n float32) error {
+	return t.WriteUInt32LE(math.Float32bits(n))
+}
+
+func (t *accessor) WriteFloat64LE(n float64) error {
+	return t.WriteUInt64LE(math.Float64bits(n))
+}
+
+func (t *accessor) WriteFloat32BE(n float32) error {
+	return t.WriteUInt32BE(math.Float32bits(n))
+}
+
+func (t *accessor) WriteFloat64BE(n float64) error {
+	return t.WriteUInt64BE(math.Float64bits(n))
+}
+
+//#endregion
@@ -0,0 +1,101 @@
+package buffer
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBuffer_WriteFloat32(t *testing.T) {
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{})
+
+		if err := buf.WriteFloat32(1.5); err != nil {
+			t.Fail()
+		}
+
+		if n, err := buf.GetUInt32(0); err != nil || n != math.Float32bits(1.5) {
+			t.Fail()
+		}
+
+		if n, err := buf.ReadFloat32(); err != nil || n != 1.5 {
+			t.Fail()
+		}
+
+		if _, err := buf.ReadFloat32(); err != ErrNoEnoughData {
+			t.Fail()
+		}
+	})
+}
+
+func TestBuffer_WriteFloat64(t *testing.T) {
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{})
+
+		values := []float64{0, math.Copysign(0, -1), math.Inf(1), math.Inf(-1), math.MaxFloat64, math.SmallestNonzeroFloat64}
+		for _, v := range values {
+			if err := buf.WriteFloat64(v); err != nil {
+				t.Fail()
+			}
+		}
+
+		for _, v := range values {
+			n, err := buf.ReadFloat64()
+			if err != nil || math.Float64bits(n) != math.Float64bits(v) {
+				t.Fail()
+			}
+		}
+
+		if _, err := buf.ReadFloat64(); err != ErrNoEnoughData {
+			t.Fail()
+		}
+	})
+}
+
+func TestBuffer_FloatNaNPayload(t *testing.T) {
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{})
+
+		nan32 := math.Float32frombits(0x7fc00123)
+		nan64 := math.Float64frombits(0x7ff8000000000abc)
+
+		buf.WriteFloat32LE(nan32)
+		buf.WriteFloat64BE(nan64)
+
+		if n, err := buf.ReadFloat32LE(); err != nil || math.Float32bits(n) != 0x7fc00123 {
+			t.Fail()
+		}
+		if n, err := buf.ReadFloat64BE(); err != nil || math.Float64bits(n) != 0x7ff8000000000abc {
+			t.Fail()
+		}
+	})
+}
+
+func TestBuffer_FloatAcrossNodes(t *testing.T) {
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{
+			MinAllocSize: 5,
+		})
+
+		buf.WriteByte(0)
+		buf.WriteFloat64(math.Pi)
+		buf.WriteFloat32LE(-math.MaxFloat32)
+		buf.WriteFloat64LE(math.Inf(-1))
+
+		if b, ok := buf.ByteBuffer.(*Buffer); ok && b.nc < 2 {
+			t.Fatal()
+		}
+
+		if n, err := buf.GetFloat64(1); err != nil || n != math.Pi {
+			t.Fail()
+		}
+		if n, err := buf.GetFloat32LE(9); err != nil || n != -math.MaxFloat32 {
+			t.Fail()
+		}
+		if n, err := buf.GetFloat64LE(13); err != nil || !math.IsInf(n, -1) {
+			t.Fail()
+		}
+		if _, err := buf.GetFloat64BE(14); err != ErrNoEnoughData {
+			t.Fail()
+		}
+	})
+}
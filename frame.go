@@ -0,0 +1,399 @@
+package buffer
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// LengthFieldVarint selects an unsigned varint length field instead of a fixed width one.
+const LengthFieldVarint = -1
+
+const maxInt = int(^uint(0) >> 1)
+
+var (
+	ErrTooLongFrame = errors.New("frame too long")
+	ErrInvalidFrame = errors.New("invalid frame length")
+	ErrForeignFrame = errors.New("frame was begun on another buffer")
+)
+
+// FrameDecoder splits a Buffer into frames using a length field in the frame header.
+// Frames longer than MaxFrameLength are reported once with ErrTooLongFrame and then
+// discarded as their bytes arrive.
+type FrameDecoder struct {
+	offset    int
+	width     int
+	order     binary.ByteOrder
+	adjust    int
+	strip     int
+	maxLength int
+	discard   int //bytes of a too long frame which are still to be discarded
+}
+
+// Decode returns the next complete frame as a new Buffer, or ErrNoEnoughData while
+// the frame has not fully arrived.
+func (t *FrameDecoder) Decode(buf *Buffer) (*Buffer, error) {
+	size, err := t.next(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return takeFrame(buf, size), nil
+}
+
+// DecodeBytes is like Decode but returns the frame as a byte slice.
+func (t *FrameDecoder) DecodeBytes(buf *Buffer) ([]byte, error) {
+	size, err := t.next(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.ReadBytes(size)
+}
+
+// next strips the initial bytes of the next complete frame and returns the remaining size.
+func (t *FrameDecoder) next(buf *Buffer) (int, error) {
+	if t.discard > 0 {
+		t.discardFrame(buf)
+		if t.discard > 0 {
+			return 0, ErrNoEnoughData
+		}
+	}
+
+	length, width, err := t.lengthField(buf)
+	if err == ErrVarintOverflow {
+		buf.skip(t.offset + binary.MaxVarintLen64)
+		return 0, ErrInvalidFrame
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	header := t.offset + width
+	if length > uint64(maxInt-header) {
+		buf.skip(header)
+		return 0, ErrInvalidFrame
+	}
+	size := header + int(length) + t.adjust
+	if size < header {
+		buf.skip(header)
+		return 0, ErrInvalidFrame
+	}
+
+	if t.maxLength > 0 && size > t.maxLength {
+		t.discard = size
+		t.discardFrame(buf)
+		return 0, ErrTooLongFrame
+	}
+	if buf.Len() < size {
+		return 0, ErrNoEnoughData
+	}
+	if size < t.strip {
+		buf.skip(size)
+		return 0, ErrInvalidFrame
+	}
+
+	buf.skip(t.strip)
+	return size - t.strip, nil
+}
+
+func (t *FrameDecoder) lengthField(buf *Buffer) (uint64, int, error) {
+	if t.width == LengthFieldVarint {
+		if err := buf.ensureReadable(t.offset + 1); err != nil {
+			return 0, 0, err
+		}
+		return buf.getUvarint(t.offset)
+	}
+
+	if err := buf.ensureReadable(t.offset + t.width); err != nil {
+		return 0, 0, err
+	}
+
+	switch t.width {
+	case 1:
+		return uint64(buf.getUInt8(t.offset)), 1, nil
+	case 2:
+		return uint64(buf.getUInt16Order(t.offset, t.order)), 2, nil
+	case 3:
+		// padded with a zero byte on the most significant side
+		var b [4]byte
+		p := b[1:]
+		if littleEndian(t.order) {
+			p = b[:3]
+		}
+		buf.copyBytes(t.offset, p)
+		return uint64(t.order.Uint32(b[:])), 3, nil
+	case 4:
+		return uint64(buf.getUInt32Order(t.offset, t.order)), 4, nil
+	default:
+		return buf.getUInt64Order(t.offset, t.order), 8, nil
+	}
+}
+
+func littleEndian(order binary.ByteOrder) bool {
+	var b [2]byte
+	order.PutUint16(b[:], 1)
+	return b[0] == 1
+}
+
+func (t *FrameDecoder) discardFrame(buf *Buffer) {
+	n := t.discard
+	if n > buf.Len() {
+		n = buf.Len()
+	}
+
+	buf.skip(n)
+	t.discard -= n
+}
+
+// DelimiterFrameDecoder splits a Buffer into frames ending with one of the delimiters.
+// It remembers how far the buffer has been searched, so it must be the only consumer
+// of the buffer it decodes. Frames longer than MaxFrameLength are reported with
+// ErrTooLongFrame and discarded up to and including the next delimiter.
+type DelimiterFrameDecoder struct {
+	delims     [][]byte
+	maxDelim   int
+	strip      bool
+	maxLength  int
+	scanned    int //bytes known not to start a delimiter
+	discarding bool
+}
+
+func (t *DelimiterFrameDecoder) Decode(buf *Buffer) (*Buffer, error) {
+	size, delim, err := t.next(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.strip {
+		frame := takeFrame(buf, size)
+		buf.skip(delim)
+		return frame, nil
+	}
+	return takeFrame(buf, size+delim), nil
+}
+
+func (t *DelimiterFrameDecoder) DecodeBytes(buf *Buffer) ([]byte, error) {
+	size, delim, err := t.next(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.strip {
+		data := buf.getBytes(0, size)
+		buf.skip(size + delim)
+		return data, nil
+	}
+	return buf.ReadBytes(size + delim)
+}
+
+// next returns the size of the next complete frame and of the delimiter ending it.
+func (t *DelimiterFrameDecoder) next(buf *Buffer) (int, int, error) {
+	for {
+		ind, delim := t.index(buf)
+		if ind < 0 {
+			t.scanned = buf.Len() - t.maxDelim + 1
+			if t.scanned < 0 {
+				t.scanned = 0
+			}
+
+			if t.discarding {
+				buf.skip(t.scanned)
+				t.scanned = 0
+				return 0, 0, ErrNoEnoughData
+			}
+			if t.maxLength > 0 && t.scanned > t.maxLength {
+				buf.skip(t.scanned)
+				t.scanned = 0
+				t.discarding = true
+				return 0, 0, ErrTooLongFrame
+			}
+			return 0, 0, ErrNoEnoughData
+		}
+
+		t.scanned = 0
+		if t.discarding {
+			buf.skip(ind + delim)
+			t.discarding = false
+			continue
+		}
+		if t.maxLength > 0 && ind > t.maxLength {
+			buf.skip(ind + delim)
+			return 0, 0, ErrTooLongFrame
+		}
+
+		return ind, delim, nil
+	}
+}
+
+// index returns the position and length of the first delimiter after the scanned bytes.
+func (t *DelimiterFrameDecoder) index(buf *Buffer) (int, int) {
+	ind, delim := -1, 0
+	for _, d := range t.delims {
+		if i := buf.Index(d, t.scanned); i >= 0 && (ind < 0 || i < ind) {
+			ind, delim = i, len(d)
+		}
+	}
+
+	return ind, delim
+}
+
+// FixedLengthFrameDecoder splits a Buffer into frames of the same size.
+type FixedLengthFrameDecoder struct {
+	length int
+}
+
+func (t *FixedLengthFrameDecoder) Decode(buf *Buffer) (*Buffer, error) {
+	if err := buf.ensureReadable(t.length); err != nil {
+		return nil, err
+	}
+
+	return takeFrame(buf, t.length), nil
+}
+
+func (t *FixedLengthFrameDecoder) DecodeBytes(buf *Buffer) ([]byte, error) {
+	return buf.ReadBytes(t.length)
+}
+
+// takeFrame moves the first size bytes of buf into a new Buffer without copying.
+func takeFrame(buf *Buffer, size int) *Buffer {
+	frame, _ := buf.Split(size)
+	return frame
+}
+
+func NewFrameDecoder(opt FrameDecoderOptions) *FrameDecoder {
+	switch opt.LengthFieldLength {
+	case 1, 2, 3, 4, 8, LengthFieldVarint:
+	default:
+		panic("LengthFieldLength should be 1, 2, 3, 4, 8 or LengthFieldVarint")
+	}
+	if opt.LengthFieldOffset < 0 {
+		panic("LengthFieldOffset cannot be negative")
+	}
+	if opt.InitialBytesToStrip < 0 {
+		panic("InitialBytesToStrip cannot be negative")
+	}
+	if opt.MaxFrameLength < 0 {
+		panic("MaxFrameLength cannot be negative")
+	}
+
+	order := opt.ByteOrder
+	if order == nil {
+		order = binary.BigEndian
+	}
+
+	d := &FrameDecoder{
+		offset:    opt.LengthFieldOffset,
+		width:     opt.LengthFieldLength,
+		order:     order,
+		adjust:    opt.LengthAdjustment,
+		strip:     opt.InitialBytesToStrip,
+		maxLength: opt.MaxFrameLength,
+	}
+	return d
+}
+
+func NewDelimiterFrameDecoder(opt DelimiterFrameDecoderOptions) *DelimiterFrameDecoder {
+	if len(opt.Delimiters) == 0 {
+		panic("Delimiters cannot be empty")
+	}
+	if opt.MaxFrameLength < 0 {
+		panic("MaxFrameLength cannot be negative")
+	}
+
+	d := &DelimiterFrameDecoder{
+		strip:     opt.StripDelimiter,
+		maxLength: opt.MaxFrameLength,
+	}
+	for _, delim := range opt.Delimiters {
+		if len(delim) == 0 {
+			panic("delimiter cannot be empty")
+		}
+		if len(delim) > d.maxDelim {
+			d.maxDelim = len(delim)
+		}
+		d.delims = append(d.delims, append([]byte(nil), delim...))
+	}
+	return d
+}
+
+func NewFixedLengthFrameDecoder(length int) *FixedLengthFrameDecoder {
+	if length <= 0 {
+		panic("length should be positive")
+	}
+
+	return &FixedLengthFrameDecoder{
+		length: length,
+	}
+}
+
+// Frame is a length header reserved by BeginFrame, filled in by EndFrame.
+type Frame struct {
+	header Placeholder
+	width  int
+}
+
+// BeginFrame reserves a length header of width bytes (1, 2, 4 or 8 in the Buffer's
+// byte order, or LengthFieldVarint) at the write position. Everything written until
+// EndFrame is the frame body. A varint header always takes binary.MaxVarintLen32
+// bytes, padded with continuation bytes, so it can be filled in place.
+func (t *Buffer) BeginFrame(width int) (Frame, error) {
+	size := width
+	switch width {
+	case 1, 2, 4, 8:
+	case LengthFieldVarint:
+		size = binary.MaxVarintLen32
+	default:
+		panic("width should be 1, 2, 4, 8 or LengthFieldVarint")
+	}
+
+	header, err := t.WritePlaceholder(size)
+	if err != nil {
+		return Frame{}, err
+	}
+
+	f := Frame{
+		header: header,
+		width:  width,
+	}
+	return f, nil
+}
+
+// EndFrame fills in the header of f with the number of bytes written since
+// BeginFrame. It fails with ErrConsumed if the header has been read meanwhile, with
+// ErrTooLongFrame if the body does not fit in the header and with ErrForeignFrame if
+// f was begun on another Buffer.
+func (t *Buffer) EndFrame(f Frame) error {
+	if f.header.buf != nil && f.header.buf != t {
+		return ErrForeignFrame
+	}
+	idx, err := f.header.index()
+	if err != nil {
+		return err
+	}
+	length := uint64(t.size - idx - f.header.Len())
+
+	switch f.width {
+	case 1:
+		if length > 0xff {
+			return ErrTooLongFrame
+		}
+		return f.header.SetUInt8(uint8(length))
+	case 2:
+		if length > 0xffff {
+			return ErrTooLongFrame
+		}
+		return f.header.SetUInt16(uint16(length))
+	case 4:
+		if length > 0xffffffff {
+			return ErrTooLongFrame
+		}
+		return f.header.SetUInt32(uint32(length))
+	case 8:
+		return f.header.SetUInt64(length)
+	default:
+		if f.header.SetVarintFixed(length) != nil {
+			return ErrTooLongFrame
+		}
+		return nil
+	}
+}
@@ -0,0 +1,369 @@
+package buffer
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestFrameDecoder_Decode(t *testing.T) {
+	d := NewFrameDecoder(FrameDecoderOptions{
+		LengthFieldLength:   2,
+		InitialBytesToStrip: 2,
+	})
+
+	buf := NewWithOptions(Options{
+		MinAllocSize: 3,
+	})
+	buf.WriteUInt16(5)
+	buf.WriteString("hel")
+
+	if _, err := d.Decode(buf); err != ErrNoEnoughData {
+		t.Fail()
+	}
+	if buf.Len() != 5 {
+		t.Fail()
+	}
+
+	buf.WriteString("lo")
+	buf.WriteUInt16(0)
+	buf.WriteUInt16(1)
+
+	frame, err := d.Decode(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := frame.ReadString(frame.Len()); s != "hello" {
+		t.Fail()
+	}
+
+	if data, err := d.DecodeBytes(buf); err != nil || len(data) != 0 {
+		t.Fail()
+	}
+	if _, err := d.DecodeBytes(buf); err != ErrNoEnoughData || buf.Len() != 2 {
+		t.Fail()
+	}
+}
+
+func TestFrameDecoder_HeaderLayout(t *testing.T) {
+	// 1 byte magic, 3 bytes little-endian length covering the whole frame
+	d := NewFrameDecoder(FrameDecoderOptions{
+		LengthFieldOffset: 1,
+		LengthFieldLength: 3,
+		ByteOrder:         binary.LittleEndian,
+		LengthAdjustment:  -4,
+	})
+
+	buf := New()
+	buf.WriteBytes([]byte{0xca, 7, 0, 0, 'a', 'b', 'c', 0xca})
+
+	data, err := d.DecodeBytes(buf)
+	if err != nil || string(data) != "\xca\x07\x00\x00abc" {
+		t.Fatal(data, err)
+	}
+	if buf.Len() != 1 {
+		t.Fail()
+	}
+}
+
+type customLittleEndian struct {
+	binary.ByteOrder
+}
+
+func TestFrameDecoder_ThreeByteLength(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.BigEndian, binary.LittleEndian, customLittleEndian{binary.LittleEndian}} {
+		d := NewFrameDecoder(FrameDecoderOptions{
+			LengthFieldLength:   3,
+			ByteOrder:           order,
+			InitialBytesToStrip: 3,
+		})
+
+		var b [4]byte
+		order.PutUint32(b[:], 0x010002)
+		header := b[1:]
+		if littleEndian(order) {
+			header = b[:3]
+		}
+
+		buf := New()
+		buf.WriteBytes(header)
+		buf.WriteBytes(make([]byte, 0x010002))
+		if data, err := d.DecodeBytes(buf); err != nil || len(data) != 0x010002 {
+			t.Fatal(order, len(data), err)
+		}
+	}
+}
+
+func TestFrameDecoder_Varint(t *testing.T) {
+	d := NewFrameDecoder(FrameDecoderOptions{
+		LengthFieldLength:   LengthFieldVarint,
+		InitialBytesToStrip: 2,
+	})
+
+	buf := New()
+	buf.WriteUvarint(300)
+	if _, err := d.DecodeBytes(buf); err != ErrNoEnoughData {
+		t.Fail()
+	}
+
+	buf.WriteBytes(make([]byte, 300))
+	if data, err := d.DecodeBytes(buf); err != nil || len(data) != 300 {
+		t.Fail()
+	}
+}
+
+func TestFrameDecoder_VarintOverflow(t *testing.T) {
+	d := NewFrameDecoder(FrameDecoderOptions{
+		LengthFieldLength: LengthFieldVarint,
+	})
+
+	buf := New()
+	buf.WriteBytes([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f})
+	buf.WriteUvarint(1)
+	buf.WriteByte('a')
+
+	if _, err := d.DecodeBytes(buf); err != ErrInvalidFrame {
+		t.Fail()
+	}
+	if data, err := d.DecodeBytes(buf); err != nil || string(data) != "\x01a" {
+		t.Fatal(data, err)
+	}
+}
+
+func TestFrameDecoder_TooLongFrame(t *testing.T) {
+	d := NewFrameDecoder(FrameDecoderOptions{
+		LengthFieldLength: 4,
+		MaxFrameLength:    10,
+	})
+
+	buf := New()
+	buf.WriteUInt32(20)
+	buf.WriteBytes(make([]byte, 6))
+
+	if _, err := d.DecodeBytes(buf); err != ErrTooLongFrame {
+		t.Fail()
+	}
+	if buf.Len() != 0 {
+		t.Fail()
+	}
+
+	buf.WriteBytes(make([]byte, 10))
+	if _, err := d.DecodeBytes(buf); err != ErrNoEnoughData {
+		t.Fail()
+	}
+
+	buf.WriteBytes(make([]byte, 4))
+	buf.WriteUInt32(2)
+	buf.WriteBytes([]byte{1, 2})
+	if data, err := d.DecodeBytes(buf); err != nil || len(data) != 6 || data[5] != 2 {
+		t.Fail()
+	}
+}
+
+func TestNewFrameDecoder(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fail()
+		}
+	}()
+
+	NewFrameDecoder(FrameDecoderOptions{
+		LengthFieldLength: 5,
+	})
+}
+
+func TestDelimiterFrameDecoder_Decode(t *testing.T) {
+	d := NewDelimiterFrameDecoder(DelimiterFrameDecoderOptions{
+		Delimiters:     [][]byte{[]byte("\r\n"), []byte("\n")},
+		StripDelimiter: true,
+	})
+
+	buf := NewWithOptions(Options{
+		MinAllocSize: 3,
+	})
+	for _, c := range []byte("hello\r") {
+		buf.WriteByte(c)
+	}
+
+	if _, err := d.DecodeBytes(buf); err != ErrNoEnoughData {
+		t.Fail()
+	}
+	if d.scanned != 5 || buf.Len() != 6 {
+		t.Fail()
+	}
+
+	for _, c := range []byte("\nworld\nfoo") {
+		buf.WriteByte(c)
+	}
+
+	if data, err := d.DecodeBytes(buf); err != nil || string(data) != "hello" {
+		t.Fail()
+	}
+	frame, err := d.Decode(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := frame.ReadString(frame.Len()); s != "world" {
+		t.Fail()
+	}
+	if _, err := d.DecodeBytes(buf); err != ErrNoEnoughData || buf.Len() != 3 {
+		t.Fail()
+	}
+}
+
+func TestDelimiterFrameDecoder_KeepDelimiter(t *testing.T) {
+	d := NewDelimiterFrameDecoder(DelimiterFrameDecoderOptions{
+		Delimiters: [][]byte{[]byte("--")},
+	})
+
+	buf := New()
+	buf.WriteString("a--b--")
+
+	if data, err := d.DecodeBytes(buf); err != nil || string(data) != "a--" {
+		t.Fail()
+	}
+	frame, err := d.Decode(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := frame.ReadString(frame.Len()); s != "b--" {
+		t.Fail()
+	}
+}
+
+func TestDelimiterFrameDecoder_TooLongFrame(t *testing.T) {
+	d := NewDelimiterFrameDecoder(DelimiterFrameDecoderOptions{
+		Delimiters:     [][]byte{[]byte("\r\n")},
+		StripDelimiter: true,
+		MaxFrameLength: 4,
+	})
+
+	buf := New()
+	buf.WriteString("123456")
+	if _, err := d.DecodeBytes(buf); err != ErrTooLongFrame {
+		t.Fail()
+	}
+
+	buf.WriteString("78\r")
+	if _, err := d.DecodeBytes(buf); err != ErrNoEnoughData {
+		t.Fail()
+	}
+
+	buf.WriteString("\nok\r\n123456\r\n")
+	if data, err := d.DecodeBytes(buf); err != nil || string(data) != "ok" {
+		t.Fail()
+	}
+	if _, err := d.DecodeBytes(buf); err != ErrTooLongFrame {
+		t.Fail()
+	}
+	if buf.Len() != 0 {
+		t.Fail()
+	}
+}
+
+func TestFixedLengthFrameDecoder_Decode(t *testing.T) {
+	d := NewFixedLengthFrameDecoder(3)
+
+	buf := New()
+	buf.WriteString("abcde")
+
+	frame, err := d.Decode(buf)
+	if err != nil || frame.Len() != 3 {
+		t.Fatal(err)
+	}
+	if _, err := d.DecodeBytes(buf); err != ErrNoEnoughData {
+		t.Fail()
+	}
+
+	buf.WriteString("f")
+	if data, err := d.DecodeBytes(buf); err != nil || string(data) != "def" {
+		t.Fail()
+	}
+}
+
+func TestBuffer_EndFrame(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 3,
+	})
+
+	f, err := buf.BeginFrame(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf.WriteUInt16(1)
+	inner, _ := buf.BeginFrame(LengthFieldVarint)
+	buf.WriteString("hello")
+	if err := buf.EndFrame(inner); err != nil {
+		t.Fail()
+	}
+	if err := buf.EndFrame(f); err != nil {
+		t.Fail()
+	}
+
+	if n, err := buf.ReadUInt32(); err != nil || n != 12 {
+		t.Fail()
+	}
+	if n, err := buf.ReadUInt16(); err != nil || n != 1 {
+		t.Fail()
+	}
+	if n, err := buf.ReadUvarint(); err != nil || n != 5 {
+		t.Fail()
+	}
+	if s, err := buf.ReadString(5); err != nil || s != "hello" {
+		t.Fail()
+	}
+}
+
+func TestBuffer_EndFrameDecode(t *testing.T) {
+	buf := New()
+	buf.WriteString("xx")
+	buf.Skip(2)
+
+	for _, body := range []string{"a", "bc", ""} {
+		f, _ := buf.BeginFrame(LengthFieldVarint)
+		buf.WriteString(body)
+		buf.EndFrame(f)
+	}
+
+	d := NewFrameDecoder(FrameDecoderOptions{
+		LengthFieldLength:   LengthFieldVarint,
+		InitialBytesToStrip: binary.MaxVarintLen32,
+	})
+	for _, body := range []string{"a", "bc", ""} {
+		if data, err := d.DecodeBytes(buf); err != nil || string(data) != body {
+			t.Fail()
+		}
+	}
+}
+
+func TestBuffer_EndFrameErrors(t *testing.T) {
+	buf := New()
+
+	f, _ := buf.BeginFrame(1)
+	buf.WriteBytes(make([]byte, 256))
+	if err := buf.EndFrame(f); err != ErrTooLongFrame {
+		t.Fail()
+	}
+
+	buf.Skip(1)
+	if err := buf.EndFrame(f); err != ErrConsumed {
+		t.Fail()
+	}
+
+	f, _ = buf.BeginFrame(1)
+	other := New()
+	other.WriteBytes(make([]byte, 8))
+	if err := other.EndFrame(f); err != ErrForeignFrame {
+		t.Fail()
+	}
+	if n, _ := other.GetUInt8(0); n != 0 {
+		t.Fail()
+	}
+
+	buf = NewWithOptions(Options{
+		MinAllocSize: 8,
+		MaxSize:      3,
+	})
+	if _, err := buf.BeginFrame(4); err != ErrExceedMaximumSize {
+		t.Fail()
+	}
+}
@@ -2,4 +2,4 @@ module github.com/scheshan/buffer
 
 go 1.16
 
-require golang.org/x/sys v0.0.0-20220811171246-fbc7d0a398ab // indirect
+require golang.org/x/sys v0.0.0-20220811171246-fbc7d0a398ab
@@ -0,0 +1,57 @@
+package buffer
+
+// Mark remembers the current read position. While a mark is held, consumed nodes are
+// kept alive instead of being released, so ResetToMark can rewind to it. A previous
+// mark is discarded.
+func (t *Buffer) Mark() {
+	t.DiscardMark()
+
+	t.marked = true
+	t.mark = 0
+	t.lastSize = 0
+}
+
+// ResetToMark moves the read position back to the mark, which stays held.
+func (t *Buffer) ResetToMark() error {
+	if !t.marked {
+		return ErrNoMark
+	}
+
+	t.rewind(t.mark)
+	t.consumed = t.off
+	t.mark = 0
+	t.lastSize = 0
+	return nil
+}
+
+// DiscardMark drops the mark and releases the nodes consumed since it was taken.
+func (t *Buffer) DiscardMark() {
+	if !t.marked {
+		return
+	}
+
+	t.marked = false
+	t.mark = 0
+	t.shrink()
+}
+
+// rewind moves the read position back by n bytes, which must still be held by the
+// nodes in front of it.
+func (t *Buffer) rewind(n int) {
+	t.size += n
+	t.off -= int64(n)
+
+	i := 0
+	for i < t.nc-1 && t.nodeAt(i).ReadableBytes() == 0 {
+		i++
+	}
+	for ; n > 0; i-- {
+		no := t.nodeAt(i)
+		back := no.r - no.h
+		if back > n {
+			back = n
+		}
+		no.r -= back
+		n -= back
+	}
+}
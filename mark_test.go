@@ -0,0 +1,130 @@
+package buffer
+
+import "testing"
+
+func TestBuffer_ResetToMark(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 3,
+	})
+
+	if err := buf.ResetToMark(); err != ErrNoMark {
+		t.Fail()
+	}
+
+	buf.WriteUInt32(1)
+	buf.WriteUInt32(2)
+	buf.ReadUInt8()
+
+	buf.Mark()
+	if n, err := buf.ReadUInt8(); err != nil || n != 0 {
+		t.Fail()
+	}
+	if n, err := buf.ReadUInt16(); err != nil || n != 1 {
+		t.Fail()
+	}
+	if _, err := buf.ReadUInt64(); err != ErrNoEnoughData {
+		t.Fail()
+	}
+	if buf.Len() != 4 {
+		t.Fail()
+	}
+
+	if err := buf.ResetToMark(); err != nil {
+		t.Fail()
+	}
+	if buf.Len() != 7 {
+		t.Fail()
+	}
+	if n, err := buf.GetUInt16(1); err != nil || n != 1 {
+		t.Fail()
+	}
+
+	// the mark is still held after a reset
+	buf.Skip(7)
+	if err := buf.ResetToMark(); err != nil || buf.Len() != 7 {
+		t.Fail()
+	}
+
+	buf.Skip(3)
+	buf.DiscardMark()
+	if err := buf.ResetToMark(); err != ErrNoMark {
+		t.Fail()
+	}
+	if n, err := buf.ReadUInt32(); err != nil || n != 2 {
+		t.Fail()
+	}
+	if buf.nc != 0 {
+		t.Fail()
+	}
+}
+
+func TestBuffer_MarkRetainsNodes(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 2,
+	})
+
+	for _, c := range []byte("abcdef") {
+		buf.WriteByte(c)
+	}
+	buf.Mark()
+
+	data, _ := buf.ReadBytes(5)
+	buf.WriteByte('g')
+	buf.WriteByte('h')
+	if buf.nc != 4 || string(data) != "abcde" {
+		t.Fail()
+	}
+
+	if err := buf.ResetToMark(); err != nil {
+		t.Fail()
+	}
+	if data, err := buf.ReadString(8); err != nil || data != "abcdefgh" {
+		t.Fail()
+	}
+
+	buf.Mark()
+	if buf.nc != 0 {
+		t.Fail()
+	}
+}
+
+func TestBuffer_MarkUnreadByte(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 1,
+	})
+	buf.WriteString("xyz")
+
+	buf.Mark()
+	buf.ReadByte()
+	buf.ReadByte()
+	if err := buf.UnreadByte(); err != nil {
+		t.Fail()
+	}
+	if b, _ := buf.GetByte(0); b != 'y' {
+		t.Fail()
+	}
+
+	buf.ResetToMark()
+	if s, err := buf.ReadString(3); err != nil || s != "xyz" {
+		t.Fail()
+	}
+}
+
+func TestBuffer_MarkReadSlices(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 2,
+	})
+	buf.WriteUInt32(0x61626364)
+
+	buf.Mark()
+	slices, err := buf.ReadSlices(3)
+	if err != nil || slices.Len() != 3 {
+		t.Fatal(err)
+	}
+	slices.Release()
+
+	buf.ResetToMark()
+	if s, err := buf.ReadString(4); err != nil || s != "abcd" {
+		t.Fail()
+	}
+}
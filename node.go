@@ -1,6 +1,9 @@
 package buffer
 
-import "sync"
+import (
+	"sync"
+	"sync/atomic"
+)
 
 var nodesPool = &sync.Pool{
 	New: func() interface{} {
@@ -8,11 +11,19 @@ var nodesPool = &sync.Pool{
 	},
 }
 
+var refsPool = &sync.Pool{
+	New: func() interface{} {
+		return new(int32)
+	},
+}
+
 type node struct {
-	buf []byte
-	r   int
-	w   int
-	adj int
+	buf  []byte
+	r    int
+	w    int
+	h    int    //start of the data, r can be moved back to it
+	off  int64  //stream offset of buf[0]
+	refs *int32 //number of nodes sharing buf
 }
 
 func (t *node) Cap() int {
@@ -23,17 +34,44 @@ func (t *node) ReadableBytes() int {
 	return t.w - t.r
 }
 
+// WritableBytes returns 0 while buf is shared, since the free space is shared too.
 func (t *node) WritableBytes() int {
+	if t.Shared() {
+		return 0
+	}
 	return t.Cap() - t.w
 }
 
+func (t *node) Shared() bool {
+	return atomic.LoadInt32(t.refs) > 1
+}
+
+// share returns a new node viewing buf[from:to] of t. buf goes back to the pool once
+// every node sharing it is released.
+func (t *node) share(from int, to int) *node {
+	atomic.AddInt32(t.refs, 1)
+
+	n := nodesPool.Get().(*node)
+	n.buf = t.buf
+	n.refs = t.refs
+	n.r = from
+	n.h = from
+	n.w = to
+	return n
+}
+
 func (t *node) Release() {
-	defaultBytesPool.put(t.buf)
+	if atomic.AddInt32(t.refs, -1) == 0 {
+		defaultBytesPool.put(t.buf)
+		refsPool.Put(t.refs)
+	}
 	t.buf = nil
+	t.refs = nil
 
 	t.w = 0
 	t.r = 0
-	t.adj = 0
+	t.h = 0
+	t.off = 0
 	nodesPool.Put(t)
 }
 
@@ -41,5 +79,7 @@ func newNode(size int) *node {
 	n := nodesPool.Get().(*node)
 
 	n.buf = defaultBytesPool.get(size)
+	n.refs = refsPool.Get().(*int32)
+	atomic.StoreInt32(n.refs, 1)
 	return n
 }
@@ -1,6 +1,39 @@
 package buffer
 
+import "encoding/binary"
+
 type Options struct {
 	MinAllocSize int
 	MaxSize      int
+	// ByteOrder is used by the Get/Read/Write methods without an explicit LE/BE suffix.
+	// Defaults to binary.BigEndian.
+	ByteOrder binary.ByteOrder
+	// MaxLineSize limits the length of a line returned by ReadLine, 0 means unlimited.
+	MaxLineSize int
+	// Headroom is the free space kept in front of the first node for Prepend.
+	Headroom int
+	// RetainSize is the capacity of drained nodes kept by the buffer for its next
+	// writes instead of returning them to the pool, 0 disables it. See Buffer.Trim.
+	RetainSize int
+}
+
+type FrameDecoderOptions struct {
+	LengthFieldOffset int
+	// LengthFieldLength is the width of the length field: 1, 2, 3, 4, 8 or LengthFieldVarint.
+	LengthFieldLength int
+	// ByteOrder of a fixed width length field, defaults to binary.BigEndian.
+	ByteOrder binary.ByteOrder
+	// LengthAdjustment is added to the length field to get the number of bytes following it.
+	LengthAdjustment    int
+	InitialBytesToStrip int
+	// MaxFrameLength rejects longer frames with ErrTooLongFrame, 0 means unlimited.
+	MaxFrameLength int
+}
+
+type DelimiterFrameDecoderOptions struct {
+	Delimiters [][]byte
+	// StripDelimiter removes the delimiter from the decoded frames.
+	StripDelimiter bool
+	// MaxFrameLength rejects longer frames with ErrTooLongFrame, 0 means unlimited.
+	MaxFrameLength int
 }
@@ -0,0 +1,113 @@
+package buffer
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var (
+	ErrPlaceholderSize = errors.New("value does not fit in the placeholder")
+	ErrEdited          = errors.New("buffer has been edited since the placeholder was written")
+)
+
+// Placeholder is a region reserved by WritePlaceholder to be filled in later. It
+// stays valid while the region is unread, even after more data has been written.
+// Once any of it has been read, it stays invalid even if bytes are put back in front
+// by Prepend or UnreadByte, unless ResetToMark rewinds before it. Insert and Delete
+// move the data, so they invalidate every outstanding placeholder.
+type Placeholder struct {
+	buf   *Buffer
+	pos   int64 //stream offset of the region
+	n     int
+	edits int //Buffer.edits when the placeholder was written
+}
+
+// WritePlaceholder reserves n zeroed bytes at the write position. n must be positive.
+func (t *Buffer) WritePlaceholder(n int) (Placeholder, error) {
+	if n <= 0 {
+		panic("n should be positive")
+	}
+	p, err := t.Reserve(n)
+	if err != nil {
+		return Placeholder{}, err
+	}
+	for i := range p {
+		p[i] = 0
+	}
+
+	ph := Placeholder{
+		buf:   t,
+		pos:   t.off + int64(t.size),
+		n:     n,
+		edits: t.edits,
+	}
+	t.Commit(n)
+	return ph, nil
+}
+
+func (t Placeholder) Len() int {
+	return t.n
+}
+
+func (t Placeholder) SetUInt8(n uint8) error {
+	return t.SetBytes([]byte{n})
+}
+
+func (t Placeholder) SetUInt16(n uint16) error {
+	var b [2]byte
+	t.buf.order.PutUint16(b[:], n)
+	return t.SetBytes(b[:])
+}
+
+func (t Placeholder) SetUInt32(n uint32) error {
+	var b [4]byte
+	t.buf.order.PutUint32(b[:], n)
+	return t.SetBytes(b[:])
+}
+
+func (t Placeholder) SetUInt64(n uint64) error {
+	var b [8]byte
+	t.buf.order.PutUint64(b[:], n)
+	return t.SetBytes(b[:])
+}
+
+// SetBytes writes p at the start of the placeholder.
+func (t Placeholder) SetBytes(p []byte) error {
+	idx, err := t.index()
+	if err != nil {
+		return err
+	}
+	if len(p) > t.n {
+		return ErrPlaceholderSize
+	}
+
+	t.buf.setBytes(idx, p)
+	return nil
+}
+
+// SetVarintFixed writes x as an unsigned varint padded to the whole placeholder,
+// which must not be longer than binary.MaxVarintLen64.
+func (t Placeholder) SetVarintFixed(x uint64) error {
+	var b [binary.MaxVarintLen64]byte
+	if t.n > len(b) || !putUvarintFixed(b[:t.n], x) {
+		return ErrPlaceholderSize
+	}
+
+	return t.SetBytes(b[:t.n])
+}
+
+// index returns the current index of the placeholder in the buffer.
+func (t Placeholder) index() (int, error) {
+	if t.buf == nil {
+		return 0, ErrConsumed
+	}
+	if t.edits != t.buf.edits {
+		return 0, ErrEdited
+	}
+
+	idx := t.pos - t.buf.off
+	if t.pos < t.buf.consumed || idx+int64(t.n) > int64(t.buf.size) {
+		return 0, ErrConsumed
+	}
+	return int(idx), nil
+}
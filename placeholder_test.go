@@ -0,0 +1,186 @@
+package buffer
+
+import "testing"
+
+func TestBuffer_WritePlaceholder(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 4,
+	})
+
+	buf.WriteByte(1)
+	ph, err := buf.WritePlaceholder(4)
+	if err != nil || ph.Len() != 4 {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		buf.WriteByte(byte(i))
+	}
+	if buf.nc < 4 {
+		t.Fatal()
+	}
+
+	if err := ph.SetUInt32(0xdeadbeef); err != nil {
+		t.Fail()
+	}
+	if n, err := buf.GetUInt32(1); err != nil || n != 0xdeadbeef {
+		t.Fail()
+	}
+
+	if err := ph.SetUInt64(1); err != ErrPlaceholderSize {
+		t.Fail()
+	}
+	if err := ph.SetBytes([]byte{9, 9}); err != nil {
+		t.Fail()
+	}
+	if n, err := buf.GetUInt32(1); err != nil || n != 0x0909beef {
+		t.Fail()
+	}
+
+	buf.Skip(1)
+	if err := ph.SetUInt16(1); err != nil {
+		t.Fail()
+	}
+	buf.Skip(1)
+	if err := ph.SetUInt8(1); err != ErrConsumed {
+		t.Fail()
+	}
+}
+
+func TestBuffer_PlaceholderPrependAfterConsume(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 16,
+		Headroom:     8,
+	})
+
+	ph, _ := buf.WritePlaceholder(4)
+	buf.WriteString("body")
+	buf.Skip(4)
+	buf.Prepend([]byte("HDR!"))
+
+	if err := ph.SetUInt32(0xdeadbeef); err != ErrConsumed {
+		t.Fail()
+	}
+	if s, _ := buf.ReadString(8); s != "HDR!body" {
+		t.Fail()
+	}
+
+	buf.WriteByte(1)
+	ph, _ = buf.WritePlaceholder(2)
+	buf.ReadByte()
+	buf.UnreadByte()
+	if err := ph.SetUInt16(1); err != nil {
+		t.Fail()
+	}
+}
+
+func TestBuffer_PlaceholderResetToMark(t *testing.T) {
+	buf := New()
+
+	ph, _ := buf.WritePlaceholder(2)
+	buf.Mark()
+	buf.Skip(2)
+	if err := ph.SetUInt16(1); err != ErrConsumed {
+		t.Fail()
+	}
+
+	buf.ResetToMark()
+	if err := ph.SetUInt16(1); err != nil {
+		t.Fail()
+	}
+	if n, err := buf.ReadUInt16(); err != nil || n != 1 {
+		t.Fail()
+	}
+}
+
+func TestBuffer_PlaceholderEdited(t *testing.T) {
+	buf := New()
+	buf.WriteString("ab")
+	ph, _ := buf.WritePlaceholder(2)
+	buf.WriteString("cd")
+
+	buf.Insert(0, []byte("XY"))
+	if err := ph.SetBytes([]byte("PP")); err != ErrEdited {
+		t.Fail()
+	}
+	if s, _ := buf.GetBytes(0, buf.Len()); string(s) != "XYab\x00\x00cd" {
+		t.Fatal(s)
+	}
+
+	ph, _ = buf.WritePlaceholder(2)
+	buf.Delete(0, 1)
+	if err := ph.SetBytes([]byte("PP")); err != ErrEdited {
+		t.Fail()
+	}
+
+	f, _ := buf.BeginFrame(1)
+	buf.Insert(buf.Len(), []byte("e"))
+	buf.Delete(0, 0)
+	if err := buf.EndFrame(f); err != nil {
+		t.Fail()
+	}
+}
+
+func TestBuffer_PlaceholderRelease(t *testing.T) {
+	buf := New()
+	buf.WriteString("ab")
+	ph, _ := buf.WritePlaceholder(2)
+	f, _ := buf.BeginFrame(1)
+
+	buf.Release()
+	buf.WriteString("xxxxxx")
+	if err := ph.SetBytes([]byte("PP")); err != ErrConsumed {
+		t.Fail()
+	}
+	if err := buf.EndFrame(f); err != ErrConsumed {
+		t.Fail()
+	}
+	if s, _ := buf.ReadString(buf.Len()); s != "xxxxxx" {
+		t.Fatal(s)
+	}
+}
+
+func TestBuffer_PlaceholderVarintFixed(t *testing.T) {
+	buf := New()
+
+	outer, _ := buf.WritePlaceholder(3)
+	buf.WriteByte(0xaa)
+	inner, _ := buf.WritePlaceholder(2)
+	buf.WriteString("value")
+
+	if err := inner.SetVarintFixed(5); err != nil {
+		t.Fail()
+	}
+	if err := outer.SetVarintFixed(uint64(buf.Len() - 3)); err != nil {
+		t.Fail()
+	}
+	if err := inner.SetVarintFixed(1 << 14); err != ErrPlaceholderSize {
+		t.Fail()
+	}
+
+	if n, err := buf.ReadUvarint(); err != nil || n != 8 {
+		t.Fail()
+	}
+	buf.Skip(1)
+	if n, err := buf.ReadUvarint(); err != nil || n != 5 {
+		t.Fail()
+	}
+	if s, err := buf.ReadString(5); err != nil || s != "value" {
+		t.Fail()
+	}
+
+	var ph Placeholder
+	if err := ph.SetUInt8(1); err != ErrConsumed {
+		t.Fail()
+	}
+}
+
+func TestBuffer_PlaceholderEmpty(t *testing.T) {
+	buf := New()
+
+	defer func() {
+		if recover() == nil {
+			t.Fail()
+		}
+	}()
+	buf.WritePlaceholder(0)
+}
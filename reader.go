@@ -0,0 +1,270 @@
+package buffer
+
+// Reader is a read cursor handed to the callback of Buffer.Decode. Its reads peek at
+// the buffer and only advance the cursor, nothing is consumed until Decode returns.
+type Reader struct {
+	buf *Buffer
+	off int
+}
+
+// Decode runs fn with a Reader positioned at the read position. The bytes read
+// through it are consumed only when fn returns nil, any error, typically
+// ErrNoEnoughData, leaves the buffer untouched.
+func (t *Buffer) Decode(fn func(r *Reader) error) error {
+	r := Reader{buf: t}
+	if err := fn(&r); err != nil {
+		return err
+	}
+
+	t.skip(r.off)
+	return nil
+}
+
+// Len returns the number of bytes not read through the Reader yet.
+func (t *Reader) Len() int {
+	return t.buf.Len() - t.off
+}
+
+// Offset returns the number of bytes read through the Reader so far.
+func (t *Reader) Offset() int {
+	return t.off
+}
+
+func (t *Reader) Skip(n int) error {
+	if err := t.buf.ensureReadable(t.off + n); err != nil {
+		return err
+	}
+
+	t.off += n
+	return nil
+}
+
+func (t *Reader) ReadBool() (bool, error) {
+	res, err := t.ReadUInt8()
+	return res != 0, err
+}
+
+func (t *Reader) ReadByte() (byte, error) {
+	return t.ReadUInt8()
+}
+
+func (t *Reader) ReadUInt8() (uint8, error) {
+	n, err := t.buf.GetUInt8(t.off)
+	if err == nil {
+		t.off++
+	}
+	return n, err
+}
+
+func (t *Reader) ReadInt8() (int8, error) {
+	res, err := t.ReadUInt8()
+	return int8(res), err
+}
+
+func (t *Reader) ReadUInt16() (uint16, error) {
+	n, err := t.buf.GetUInt16(t.off)
+	if err == nil {
+		t.off += 2
+	}
+	return n, err
+}
+
+func (t *Reader) ReadInt16() (int16, error) {
+	res, err := t.ReadUInt16()
+	return int16(res), err
+}
+
+func (t *Reader) ReadUInt32() (uint32, error) {
+	n, err := t.buf.GetUInt32(t.off)
+	if err == nil {
+		t.off += 4
+	}
+	return n, err
+}
+
+func (t *Reader) ReadInt32() (int32, error) {
+	res, err := t.ReadUInt32()
+	return int32(res), err
+}
+
+func (t *Reader) ReadUInt64() (uint64, error) {
+	n, err := t.buf.GetUInt64(t.off)
+	if err == nil {
+		t.off += 8
+	}
+	return n, err
+}
+
+func (t *Reader) ReadInt64() (int64, error) {
+	res, err := t.ReadUInt64()
+	return int64(res), err
+}
+
+func (t *Reader) ReadUInt16LE() (uint16, error) {
+	n, err := t.buf.GetUInt16LE(t.off)
+	if err == nil {
+		t.off += 2
+	}
+	return n, err
+}
+
+func (t *Reader) ReadInt16LE() (int16, error) {
+	res, err := t.ReadUInt16LE()
+	return int16(res), err
+}
+
+func (t *Reader) ReadUInt32LE() (uint32, error) {
+	n, err := t.buf.GetUInt32LE(t.off)
+	if err == nil {
+		t.off += 4
+	}
+	return n, err
+}
+
+func (t *Reader) ReadInt32LE() (int32, error) {
+	res, err := t.ReadUInt32LE()
+	return int32(res), err
+}
+
+func (t *Reader) ReadUInt64LE() (uint64, error) {
+	n, err := t.buf.GetUInt64LE(t.off)
+	if err == nil {
+		t.off += 8
+	}
+	return n, err
+}
+
+func (t *Reader) ReadInt64LE() (int64, error) {
+	res, err := t.ReadUInt64LE()
+	return int64(res), err
+}
+
+func (t *Reader) ReadUInt16BE() (uint16, error) {
+	n, err := t.buf.GetUInt16BE(t.off)
+	if err == nil {
+		t.off += 2
+	}
+	return n, err
+}
+
+func (t *Reader) ReadInt16BE() (int16, error) {
+	res, err := t.ReadUInt16BE()
+	return int16(res), err
+}
+
+func (t *Reader) ReadUInt32BE() (uint32, error) {
+	n, err := t.buf.GetUInt32BE(t.off)
+	if err == nil {
+		t.off += 4
+	}
+	return n, err
+}
+
+func (t *Reader) ReadInt32BE() (int32, error) {
+	res, err := t.ReadUInt32BE()
+	return int32(res), err
+}
+
+func (t *Reader) ReadUInt64BE() (uint64, error) {
+	n, err := t.buf.GetUInt64BE(t.off)
+	if err == nil {
+		t.off += 8
+	}
+	return n, err
+}
+
+func (t *Reader) ReadInt64BE() (int64, error) {
+	res, err := t.ReadUInt64BE()
+	return int64(res), err
+}
+
+func (t *Reader) ReadUInt() (uint, error) {
+	res, err := t.ReadUInt64()
+	return uint(res), err
+}
+
+func (t *Reader) ReadInt() (int, error) {
+	res, err := t.ReadInt64()
+	return int(res), err
+}
+
+func (t *Reader) ReadFloat32() (float32, error) {
+	n, err := t.buf.GetFloat32(t.off)
+	if err == nil {
+		t.off += 4
+	}
+	return n, err
+}
+
+func (t *Reader) ReadFloat64() (float64, error) {
+	n, err := t.buf.GetFloat64(t.off)
+	if err == nil {
+		t.off += 8
+	}
+	return n, err
+}
+
+func (t *Reader) ReadFloat32LE() (float32, error) {
+	n, err := t.buf.GetFloat32LE(t.off)
+	if err == nil {
+		t.off += 4
+	}
+	return n, err
+}
+
+func (t *Reader) ReadFloat64LE() (float64, error) {
+	n, err := t.buf.GetFloat64LE(t.off)
+	if err == nil {
+		t.off += 8
+	}
+	return n, err
+}
+
+func (t *Reader) ReadFloat32BE() (float32, error) {
+	n, err := t.buf.GetFloat32BE(t.off)
+	if err == nil {
+		t.off += 4
+	}
+	return n, err
+}
+
+func (t *Reader) ReadFloat64BE() (float64, error) {
+	n, err := t.buf.GetFloat64BE(t.off)
+	if err == nil {
+		t.off += 8
+	}
+	return n, err
+}
+
+func (t *Reader) ReadUvarint() (uint64, error) {
+	x, n, err := t.buf.GetUvarint(t.off)
+	if err == nil {
+		t.off += n
+	}
+	return x, err
+}
+
+func (t *Reader) ReadVarint() (int64, error) {
+	x, n, err := t.buf.GetVarint(t.off)
+	if err == nil {
+		t.off += n
+	}
+	return x, err
+}
+
+func (t *Reader) ReadBytes(size int) ([]byte, error) {
+	data, err := t.buf.GetBytes(t.off, size)
+	if err == nil {
+		t.off += size
+	}
+	return data, err
+}
+
+func (t *Reader) ReadString(n int) (string, error) {
+	data, err := t.ReadBytes(n)
+	if err != nil {
+		return "", err
+	}
+
+	return bytesToString(data), nil
+}
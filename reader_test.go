@@ -0,0 +1,93 @@
+package buffer
+
+import (
+	"errors"
+	"testing"
+)
+
+func decodeMessage(buf *Buffer) (uint16, string, error) {
+	var kind uint16
+	var body string
+
+	err := buf.Decode(func(r *Reader) error {
+		var err error
+		if kind, err = r.ReadUInt16(); err != nil {
+			return err
+		}
+
+		size, err := r.ReadUvarint()
+		if err != nil {
+			return err
+		}
+		body, err = r.ReadString(int(size))
+		return err
+	})
+
+	return kind, body, err
+}
+
+func TestBuffer_Decode(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 3,
+	})
+
+	buf.WriteUInt16(7)
+	buf.WriteUvarint(5)
+	buf.WriteBytes([]byte("he"))
+
+	if _, _, err := decodeMessage(buf); err != ErrNoEnoughData {
+		t.Fail()
+	}
+	if buf.Len() != 5 {
+		t.Fail()
+	}
+
+	buf.WriteBytes([]byte("llo!"))
+	kind, body, err := decodeMessage(buf)
+	if err != nil || kind != 7 || body != "hello" {
+		t.Fail()
+	}
+	if buf.Len() != 1 {
+		t.Fail()
+	}
+}
+
+func TestBuffer_DecodeError(t *testing.T) {
+	buf := New()
+	buf.WriteUInt32LE(10)
+	buf.WriteFloat64(1.5)
+
+	errInvalid := errors.New("invalid")
+	err := buf.Decode(func(r *Reader) error {
+		if n, err := r.ReadUInt32LE(); err != nil || n != 10 {
+			return errInvalid
+		}
+		if r.Offset() != 4 || r.Len() != 8 {
+			return errInvalid
+		}
+		if f, err := r.ReadFloat64(); err != nil || f != 1.5 {
+			return errInvalid
+		}
+		return errInvalid
+	})
+	if err != errInvalid || buf.Len() != 12 {
+		t.Fail()
+	}
+
+	err = buf.Decode(func(r *Reader) error {
+		if err := r.Skip(4); err != nil {
+			return err
+		}
+		return r.Skip(9)
+	})
+	if err != ErrNoEnoughData || buf.Len() != 12 {
+		t.Fail()
+	}
+
+	err = buf.Decode(func(r *Reader) error {
+		return r.Skip(4)
+	})
+	if err != nil || buf.Len() != 8 {
+		t.Fail()
+	}
+}
@@ -0,0 +1,331 @@
+package buffer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"golang.org/x/sys/unix"
+	"io"
+	"net"
+)
+
+// ByteBuffer is implemented by Buffer and RingBuffer. Both also share the typed Get,
+// Read, Write and Set methods, in the default, LE and BE byte orders.
+type ByteBuffer interface {
+	io.Reader
+	io.Writer
+	io.ByteReader
+	io.ByteWriter
+	io.ReaderFrom
+	io.WriterTo
+
+	Len() int
+	Skip(n int) error
+	FindByte(ind int, b byte) (int, bool, error)
+	ReadToFd(fd int) (int, error)
+	WriteFromFd(fd int) (int, error)
+	Release()
+}
+
+var (
+	_ ByteBuffer = (*Buffer)(nil)
+	_ ByteBuffer = (*RingBuffer)(nil)
+)
+
+// RingBuffer is a fixed capacity buffer backed by a single pooled slice, which the
+// data wraps around. Writes beyond the capacity fail with ErrExceedMaximumSize.
+// Besides ByteBuffer it has the typed accessors of Buffer. Marks, Reserve, Prepend,
+// Insert and Delete, sharing, placeholders and frames, pattern search, and line and
+// rune reads are specific to Buffer.
+type RingBuffer struct {
+	accessor
+	buf  []byte //allocated on the first write, len(buf) is the capacity
+	r    int
+	size int
+	cap  int
+	iovs [][]byte //reused by readv/writev
+}
+
+//#region read logic
+
+func (t *RingBuffer) FindByte(ind int, b byte) (int, bool, error) {
+	if err := t.ensureReadable(ind); err != nil {
+		return -1, false, err
+	}
+
+	head, tail := t.readable(ind, t.size-ind)
+	if i := bytes.IndexByte(head, b); i >= 0 {
+		return ind + i, true, nil
+	}
+	if i := bytes.IndexByte(tail, b); i >= 0 {
+		return ind + len(head) + i, true, nil
+	}
+	return -1, false, nil
+}
+
+func (t *RingBuffer) Read(p []byte) (n int, err error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if t.size == 0 {
+		return 0, io.EOF
+	}
+
+	n = len(p)
+	if n > t.size {
+		n = t.size
+	}
+	t.copyBytes(0, p[:n])
+	t.skip(n)
+
+	return n, nil
+}
+
+// WriteTo writes the readable bytes to w directly, as net.Buffers when w is a
+// net.Conn so that wrapped data goes out in a single writev.
+func (t *RingBuffer) WriteTo(w io.Writer) (n int64, err error) {
+	head, tail := t.readable(0, t.size)
+
+	if _, ok := w.(net.Conn); ok {
+		bufs := net.Buffers{head, tail}
+		n, err = bufs.WriteTo(w)
+		t.skip(int(n))
+		return
+	}
+
+	for _, p := range [][]byte{head, tail} {
+		if len(p) == 0 {
+			continue
+		}
+
+		n0, e0 := w.Write(p)
+		n += int64(n0)
+		if e0 != nil {
+			err = e0
+			break
+		}
+		if n0 < len(p) {
+			err = io.ErrShortWrite
+			break
+		}
+	}
+	t.skip(int(n))
+
+	return
+}
+
+// ReadToFd writes all the readable bytes to fd, with a writev when they wrap around.
+func (t *RingBuffer) ReadToFd(fd int) (int, error) {
+	head, tail := t.readable(0, t.size)
+	if len(head) == 0 {
+		return 0, nil
+	}
+
+	t.iovs = append(t.iovs, head)
+	if len(tail) > 0 {
+		t.iovs = append(t.iovs, tail)
+	}
+	n, err := unix.Writev(fd, t.iovs)
+	t.clearIovs()
+	if n < 0 {
+		n = 0
+	}
+	t.skip(n)
+
+	return n, err
+}
+
+//#endregion
+
+//#region write logic
+
+// WriteFromFd fills the free space from fd, with a readv when it wraps around.
+func (t *RingBuffer) WriteFromFd(fd int) (int, error) {
+	if err := t.ensureWriteable(1); err != nil {
+		return 0, err
+	}
+
+	head, tail := t.writable()
+	t.iovs = append(t.iovs, head)
+	if len(tail) > 0 {
+		t.iovs = append(t.iovs, tail)
+	}
+	n, err := unix.Readv(fd, t.iovs)
+	t.clearIovs()
+	if n < 0 {
+		n = 0
+	}
+	t.size += n
+
+	return n, err
+}
+
+// ReadFrom reads from r into the free space until io.EOF, which is not reported as
+// an error. ErrExceedMaximumSize is returned once the buffer is full.
+func (t *RingBuffer) ReadFrom(r io.Reader) (n int64, err error) {
+	for {
+		if e0 := t.ensureWriteable(1); e0 != nil {
+			return n, e0
+		}
+
+		head, _ := t.writable()
+		n0, e0 := r.Read(head)
+		if n0 < 0 {
+			panic("reader returned negative count from Read")
+		}
+		t.size += n0
+		n += int64(n0)
+
+		if e0 == io.EOF {
+			return n, nil
+		}
+		if e0 != nil {
+			return n, e0
+		}
+	}
+}
+
+//#endregion
+
+//#region common logic
+
+func (t *RingBuffer) Len() int {
+	return t.size
+}
+
+func (t *RingBuffer) Cap() int {
+	return t.cap
+}
+
+// Release drops the data and returns the backing slice to the pool. The buffer can
+// still be used, it allocates a new slice on the next write.
+func (t *RingBuffer) Release() {
+	if t.buf != nil {
+		defaultBytesPool.put(t.buf[:cap(t.buf)])
+		t.buf = nil
+	}
+	t.r = 0
+	t.size = 0
+}
+
+//#endregion
+
+// pos returns the index in buf of the idx-th readable byte.
+func (t *RingBuffer) pos(idx int) int {
+	p := t.r + idx
+	if p >= t.cap {
+		p -= t.cap
+	}
+	return p
+}
+
+// readable returns the size bytes starting at idx, split in two at the wrap point.
+func (t *RingBuffer) readable(idx int, size int) ([]byte, []byte) {
+	if size == 0 {
+		return nil, nil
+	}
+
+	p := t.pos(idx)
+	if p+size <= t.cap {
+		return t.buf[p : p+size], nil
+	}
+	return t.buf[p:], t.buf[:p+size-t.cap]
+}
+
+// writable returns the free space after the readable bytes, split at the wrap point.
+// buf must have been allocated by ensureWriteable.
+func (t *RingBuffer) writable() ([]byte, []byte) {
+	w := t.pos(t.size)
+	if w < t.r || t.size == t.cap {
+		return t.buf[w:t.r], nil
+	}
+	return t.buf[w:], t.buf[:t.r]
+}
+
+// write appends b, which must fit in the free space.
+func (t *RingBuffer) write(b []byte) {
+	head, tail := t.writable()
+	n := copy(head, b)
+	copy(tail, b[n:])
+	t.size += len(b)
+}
+
+// peek returns len(b) bytes starting at idx, in place unless they wrap around.
+func (t *RingBuffer) peek(idx int, b []byte) []byte {
+	head, tail := t.readable(idx, len(b))
+	if len(tail) == 0 {
+		return head
+	}
+
+	copy(b[copy(b, head):], tail)
+	return b
+}
+
+func (t *RingBuffer) copyBytes(idx int, p []byte) {
+	head, tail := t.readable(idx, len(p))
+	copy(p[copy(p, head):], tail)
+}
+
+func (t *RingBuffer) setBytes(idx int, p []byte) {
+	head, tail := t.readable(idx, len(p))
+	copy(tail, p[copy(head, p):])
+}
+
+func (t *RingBuffer) skip(n int) {
+	t.size -= n
+	t.r = t.pos(n)
+}
+
+func (t *RingBuffer) ensureReadable(size int) error {
+	if size < 0 {
+		panic("invalid argument")
+	}
+	if t.size < size {
+		return ErrNoEnoughData
+	}
+	return nil
+}
+
+func (t *RingBuffer) ensureWriteable(size int) error {
+	if t.cap-t.size < size {
+		return ErrExceedMaximumSize
+	}
+	if t.buf == nil {
+		t.buf = defaultBytesPool.get(t.cap)[:t.cap]
+	}
+
+	return nil
+}
+
+// clearIovs drops the references to buf held by t.iovs, keeping its capacity.
+func (t *RingBuffer) clearIovs() {
+	for i := range t.iovs {
+		t.iovs[i] = nil
+	}
+	t.iovs = t.iovs[:0]
+}
+
+// NewRing returns a RingBuffer holding at most size bytes.
+func NewRing(size int) *RingBuffer {
+	return NewRingWithOptions(Options{
+		MaxSize: size,
+	})
+}
+
+// NewRingWithOptions returns a RingBuffer whose capacity is opt.MaxSize. Only
+// ByteOrder is used among the other options.
+func NewRingWithOptions(opt Options) *RingBuffer {
+	if opt.MaxSize <= 0 {
+		panic("MaxSize should be positive")
+	}
+
+	order := opt.ByteOrder
+	if order == nil {
+		order = binary.BigEndian
+	}
+
+	buf := &RingBuffer{
+		cap: opt.MaxSize,
+	}
+	buf.accessor = accessor{buf, order}
+	return buf
+}
@@ -0,0 +1,179 @@
+package buffer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"golang.org/x/sys/unix"
+	"testing"
+)
+
+func TestRingBuffer_ByteOrder(t *testing.T) {
+	buf := NewRingWithOptions(Options{
+		MaxSize:   16,
+		ByteOrder: binary.LittleEndian,
+	})
+
+	buf.WriteUInt32(0x01020304)
+	if n, _ := buf.GetUInt8(0); n != 4 {
+		t.Fail()
+	}
+	if n, err := buf.ReadUInt32(); err != nil || n != 0x01020304 {
+		t.Fail()
+	}
+}
+
+func TestRingBuffer_HalfReadWrite(t *testing.T) {
+	buf := NewRing(12)
+
+	for i := 0; i < 10; i++ {
+		if err := buf.WriteInt64(int64(i)); err != nil {
+			t.Fatal(i, err)
+		}
+		if n, err := buf.GetInt64(0); err != nil || n != int64(i) {
+			t.Fatal(i, n)
+		}
+		buf.WriteByte(byte(i))
+		if n, err := buf.ReadInt64(); err != nil || n != int64(i) {
+			t.Fatal(i, n)
+		}
+		if b, err := buf.ReadByte(); err != nil || b != byte(i) {
+			t.Fatal(i, b)
+		}
+		buf.WriteUInt16(uint16(i))
+		buf.Skip(2)
+	}
+
+	if _, err := buf.ReadInt64(); err != ErrNoEnoughData {
+		t.Fail()
+	}
+}
+
+func TestRingBuffer_Full(t *testing.T) {
+	buf := NewRing(8)
+
+	buf.WriteUInt32(1)
+	if err := buf.WriteUInt64(1); err != ErrExceedMaximumSize || buf.Len() != 4 {
+		t.Fail()
+	}
+	if err := buf.WriteUInt32(2); err != nil {
+		t.Fail()
+	}
+	if err := buf.WriteByte(1); err != ErrExceedMaximumSize {
+		t.Fail()
+	}
+
+	buf.Skip(4)
+	if err := buf.WriteUInt32(3); err != nil {
+		t.Fail()
+	}
+	if n, err := buf.ReadUInt64(); err != nil || n != 2<<32|3 {
+		t.Fail()
+	}
+}
+
+func TestRingBuffer_FindByte(t *testing.T) {
+	buf := NewRing(9)
+	buf.WriteBytes([]byte{0, 0, 0, 0})
+	buf.Skip(4)
+
+	buf.WriteBytes([]byte{1, 2, 3, 4, 5, 6, 7, 8, 9})
+
+	if ind, ok, err := buf.FindByte(0, 5); !ok || err != nil || ind != 4 {
+		t.Fail()
+	}
+	if ind, ok, err := buf.FindByte(6, 9); !ok || err != nil || ind != 8 {
+		t.Fail()
+	}
+	if ind, ok, err := buf.FindByte(8, 5); ok || err != nil || ind != -1 {
+		t.Fail()
+	}
+	if _, _, err := buf.FindByte(10, 5); err != ErrNoEnoughData {
+		t.Fail()
+	}
+}
+
+func TestRingBuffer_GetBytes(t *testing.T) {
+	buf := NewRing(9)
+	buf.WriteBytes([]byte{0, 0, 0, 0, 0})
+	buf.Skip(5)
+
+	buf.WriteBytes([]byte{1, 2, 3, 4, 5, 6, 7, 8, 9})
+
+	data, err := buf.GetBytes(3, 5)
+	if err != nil {
+		t.Fail()
+	}
+	if data[0] != 4 || data[4] != 8 {
+		t.Fail()
+	}
+	if n, err := buf.GetUInt16(3); err != nil || n != 0x0405 {
+		t.Fail()
+	}
+}
+
+func TestRingBuffer_ReadToFd(t *testing.T) {
+	var fds [2]int
+	if err := unix.Pipe(fds[:]); err != nil {
+		t.Fatal(err)
+	}
+	defer unix.Close(fds[0])
+	defer unix.Close(fds[1])
+
+	buf := NewRing(16)
+	buf.WriteUInt64(0)
+	buf.Skip(8)
+	buf.WriteUInt64(1)
+	buf.WriteUInt64(2)
+
+	if n, err := buf.ReadToFd(fds[1]); err != nil || n != 16 || buf.Len() != 0 {
+		t.Fatal(n, err)
+	}
+	if n, err := buf.ReadToFd(fds[1]); err != nil || n != 0 {
+		t.Fatal(n, err)
+	}
+
+	buf.WriteUInt32(0)
+	buf.Skip(4)
+	if n, err := buf.WriteFromFd(fds[0]); err != nil || n != 16 {
+		t.Fatal(n, err)
+	}
+	if _, err := buf.WriteFromFd(fds[0]); err != ErrExceedMaximumSize {
+		t.Fail()
+	}
+	if n, err := buf.ReadUInt64(); err != nil || n != 1 {
+		t.Fail()
+	}
+	if n, err := buf.ReadUInt64(); err != nil || n != 2 {
+		t.Fail()
+	}
+}
+
+func TestRingBuffer_WrapAround(t *testing.T) {
+	buf := NewRing(8)
+	buf.WriteBytes(make([]byte, 6))
+	buf.Skip(6)
+
+	buf.WriteUInt32(0)
+	buf.WriteUvarint(300)
+	if err := buf.SetUInt32LE(0, 0x01020304); err != nil {
+		t.Fail()
+	}
+	if n, err := buf.GetUInt32BE(0); err != nil || n != 0x04030201 {
+		t.Fail()
+	}
+	if n, _, err := buf.GetUvarint(4); err != nil || n != 300 {
+		t.Fail()
+	}
+
+	var out bytes.Buffer
+	if n, err := buf.WriteTo(&out); err != nil || n != 6 || buf.Len() != 0 {
+		t.Fatal(n, err)
+	}
+	if !bytes.Equal(out.Bytes(), []byte{4, 3, 2, 1, 0xac, 0x02}) {
+		t.Fail()
+	}
+
+	if n, err := buf.ReadFrom(bytes.NewReader(make([]byte, 10))); err != ErrExceedMaximumSize || n != 8 {
+		t.Fatal(n, err)
+	}
+}
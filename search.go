@@ -0,0 +1,151 @@
+package buffer
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+)
+
+// Index returns the index of the first occurrence of p at or after from, or -1. Each
+// node is searched with bytes.Index, and only the few bytes around node boundaries
+// are gathered to find occurrences spanning nodes.
+func (t *Buffer) Index(p []byte, from int) int {
+	if from < 0 {
+		panic("invalid argument")
+	}
+
+	m := len(p)
+	switch {
+	case m == 0:
+		if from > t.size {
+			return -1
+		}
+		return from
+	case m == 1:
+		return t.indexByte(from, p[0])
+	case from+m > t.size:
+		return -1
+	}
+
+	var window []byte
+	i, ni := t.getNode(from)
+	for i < t.nc {
+		no := t.nodeAt(i)
+		seg := no.buf[ni:no.w]
+		base := t.indexOf(no, ni)
+
+		if k := bytes.Index(seg, p); k >= 0 {
+			return base + k
+		}
+
+		// occurrences starting in the last m-1 bytes of seg and ending in following nodes
+		tail := m - 1
+		if tail > len(seg) {
+			tail = len(seg)
+		}
+		start := base + len(seg) - tail
+		end := start + tail + m - 1
+		if end > t.size {
+			end = t.size
+		}
+		if end-start < m {
+			return -1
+		}
+
+		if window == nil {
+			window = make([]byte, 2*(m-1))
+		}
+		t.copyBytes(start, window[:end-start])
+		if k := bytes.Index(window[:end-start], p); k >= 0 && k < tail {
+			return start + k
+		}
+
+		i++
+		if i < t.nc {
+			ni = t.nodeAt(i).r
+		}
+	}
+
+	return -1
+}
+
+// IndexAny returns the index of the first occurrence of any of the UTF-8 encoded
+// code points in chars, or -1.
+func (t *Buffer) IndexAny(chars string) int {
+	if chars == "" {
+		return -1
+	}
+
+	for i := 0; i < len(chars); i++ {
+		if chars[i] >= utf8.RuneSelf {
+			return t.indexAnyRune(chars)
+		}
+	}
+
+	for i := 0; i < t.nc; i++ {
+		no := t.nodeAt(i)
+		if k := bytes.IndexAny(no.buf[no.r:no.w], chars); k >= 0 {
+			return t.indexOf(no, no.r+k)
+		}
+	}
+
+	return -1
+}
+
+// LastIndexByte returns the index of the last c, or -1.
+func (t *Buffer) LastIndexByte(c byte) int {
+	for i := t.nc - 1; i >= 0; i-- {
+		no := t.nodeAt(i)
+		if k := bytes.LastIndexByte(no.buf[no.r:no.w], c); k >= 0 {
+			return t.indexOf(no, no.r+k)
+		}
+	}
+
+	return -1
+}
+
+func (t *Buffer) Contains(p []byte) bool {
+	return t.Index(p, 0) >= 0
+}
+
+func (t *Buffer) indexByte(from int, c byte) int {
+	if from >= t.size {
+		return -1
+	}
+
+	i, ni := t.getNode(from)
+	for i < t.nc {
+		no := t.nodeAt(i)
+		if k := bytes.IndexByte(no.buf[ni:no.w], c); k >= 0 {
+			return t.indexOf(no, ni+k)
+		}
+
+		i++
+		if i < t.nc {
+			ni = t.nodeAt(i).r
+		}
+	}
+
+	return -1
+}
+
+// indexAnyRune is the slow path of IndexAny for non-ASCII chars, decoding the buffer
+// rune by rune since an encoding may be split across nodes.
+func (t *Buffer) indexAnyRune(chars string) int {
+	var b [utf8.UTFMax]byte
+
+	for idx := 0; idx < t.size; {
+		l := len(b)
+		if l > t.size-idx {
+			l = t.size - idx
+		}
+
+		r, size := utf8.DecodeRune(t.peek(idx, b[:l]))
+		if strings.ContainsRune(chars, r) {
+			return idx
+		}
+		idx += size
+	}
+
+	return -1
+}
@@ -0,0 +1,103 @@
+package buffer
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestBuffer_Index(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 4,
+	})
+	buf.WriteString("GET / HTTP/1.1\r\nHost: a\r\n\r\nbody\r\n\r\n")
+
+	if ind := buf.Index([]byte("\r\n\r\n"), 0); ind != 23 {
+		t.Fatal(ind)
+	}
+	if ind := buf.Index([]byte("\r\n\r\n"), 24); ind != 31 {
+		t.Fatal(ind)
+	}
+	if ind := buf.Index([]byte("\r\n\r\n"), 32); ind != -1 {
+		t.Fatal(ind)
+	}
+	if ind := buf.Index([]byte("H"), 5); ind != 6 {
+		t.Fatal(ind)
+	}
+	if ind := buf.Index(nil, 3); ind != 3 {
+		t.Fatal(ind)
+	}
+	if !buf.Contains([]byte("Host")) || buf.Contains([]byte("Hosts")) {
+		t.Fail()
+	}
+}
+
+func TestBuffer_IndexRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for n := 0; n < 200; n++ {
+		data := make([]byte, r.Intn(200))
+		for i := range data {
+			data[i] = byte('a' + r.Intn(3))
+		}
+
+		buf := NewWithOptions(Options{
+			MinAllocSize: 1 + r.Intn(8),
+		})
+		for _, b := range data {
+			buf.WriteByte(b)
+		}
+
+		p := make([]byte, 1+r.Intn(6))
+		for i := range p {
+			p[i] = byte('a' + r.Intn(3))
+		}
+		from := 0
+		if len(data) > 0 {
+			from = r.Intn(len(data))
+		}
+
+		want := bytes.Index(data[from:], p)
+		if want >= 0 {
+			want += from
+		}
+		if got := buf.Index(p, from); got != want {
+			t.Fatal(string(data), string(p), from, got, want)
+		}
+	}
+}
+
+func TestBuffer_IndexAny(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 2,
+	})
+	buf.WriteString("abc世界")
+
+	if ind := buf.IndexAny("xc"); ind != 2 {
+		t.Fail()
+	}
+	if ind := buf.IndexAny("界"); ind != 6 {
+		t.Fail()
+	}
+	if ind := buf.IndexAny("xyz"); ind != -1 {
+		t.Fail()
+	}
+	if ind := buf.IndexAny(""); ind != -1 {
+		t.Fail()
+	}
+}
+
+func TestBuffer_LastIndexByte(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 2,
+	})
+	buf.WriteString("a/b/c/d")
+	buf.Skip(1)
+
+	if ind := buf.LastIndexByte('/'); ind != 4 {
+		t.Fail()
+	}
+	if ind := buf.LastIndexByte('x'); ind != -1 {
+		t.Fail()
+	}
+}
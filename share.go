@@ -0,0 +1,142 @@
+package buffer
+
+// Slice returns a new Buffer holding the readable bytes in [idx, idx+size), sharing
+// their memory with t instead of copying it. Both buffers have independent read
+// and write positions; the memory goes back to the pool once both released it.
+// Bytes overwritten by Set methods are seen by every buffer sharing them.
+func (t *Buffer) Slice(idx int, size int) (*Buffer, error) {
+	if err := t.ensureReadable(idx); err != nil {
+		return nil, err
+	}
+	if err := t.ensureReadable(idx + size); err != nil {
+		return nil, err
+	}
+
+	buf := newLike(t)
+	if size == 0 {
+		return buf, nil
+	}
+
+	i, ni := t.getNode(idx)
+	for n := size; n > 0; {
+		no := t.nodeAt(i)
+		end := no.w
+		if end-ni > n {
+			end = ni + n
+		}
+
+		buf.addNode(no.share(ni, end))
+		n -= end - ni
+
+		i++
+		if i < t.nc {
+			ni = t.nodeAt(i).r
+		}
+	}
+	buf.size = size
+
+	return buf, nil
+}
+
+// Duplicate returns a new Buffer sharing all the readable bytes of t, see Slice.
+func (t *Buffer) Duplicate() *Buffer {
+	buf, _ := t.Slice(0, t.size)
+	return buf
+}
+
+// Split detaches the first size readable bytes into a new Buffer. Whole nodes are
+// moved and at most one node is split, sharing its memory, so nothing is copied.
+func (t *Buffer) Split(size int) (*Buffer, error) {
+	if err := t.ensureReadable(size); err != nil {
+		return nil, err
+	}
+
+	buf := newLike(t)
+	for _, no := range t.take(size) {
+		buf.addNode(no)
+	}
+	buf.size = size
+
+	return buf, nil
+}
+
+// AppendBuffer moves the readable nodes of other to the end of t, leaving other empty.
+func (t *Buffer) AppendBuffer(other *Buffer) error {
+	if other == t {
+		panic("cannot append a buffer to itself")
+	}
+	if err := t.ensureWriteable(other.size); err != nil {
+		return err
+	}
+
+	// an empty writer would be left in the middle, in front of the nodes of other
+	if tail := t.writer(); tail != nil && tail.ReadableBytes() == 0 && (!t.marked || tail.r == tail.h) {
+		t.setNodeAt(t.nc-1, nil)
+		t.nc--
+		t.retire(tail)
+	}
+
+	for other.nc > 0 {
+		no := other.popFront()
+		if no.ReadableBytes() == 0 {
+			other.retire(no)
+			continue
+		}
+
+		no.h = no.r
+		t.addNode(no)
+	}
+	t.size += other.size
+
+	other.forward(other.size)
+	other.size = 0
+	other.lastSize = 0
+	other.marked = false
+	other.mark = 0
+	return nil
+}
+
+// take consumes size bytes and returns nodes holding them. Nodes read completely are
+// detached, a node read partially is shared. While a mark is held the consumed
+// nodes must stay in place, so they are all shared.
+func (t *Buffer) take(size int) []*node {
+	var res []*node
+
+	if t.marked {
+		for i, n := 0, size; n > 0; i++ {
+			no := t.nodeAt(i)
+			l := no.ReadableBytes()
+			if l > n {
+				l = n
+			}
+			if l > 0 {
+				res = append(res, no.share(no.r, no.r+l))
+			}
+			n -= l
+		}
+		t.skip(size)
+		return res
+	}
+
+	k := 0
+	for n := size; n > 0; {
+		no := t.nodeAt(k)
+		if avail := no.ReadableBytes(); avail <= n {
+			no.h = no.r
+			res = append(res, no)
+			n -= avail
+			k++
+		} else {
+			res = append(res, no.share(no.r, no.r+n))
+			no.r += n
+			n = 0
+		}
+	}
+
+	t.detach(k)
+	t.size -= size
+	t.forward(size)
+	t.lastSize = 0
+
+	return res
+}
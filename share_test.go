@@ -0,0 +1,255 @@
+package buffer
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+func TestBuffer_Duplicate(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 4,
+	})
+	buf.WriteUInt32(1)
+	buf.WriteUInt16(2)
+
+	dup := buf.Duplicate()
+	if dup.Len() != 6 || dup.nc != 2 {
+		t.Fatal()
+	}
+	if atomic.LoadInt32(buf.nodeAt(0).refs) != 2 {
+		t.Fail()
+	}
+
+	if n, err := dup.ReadUInt32(); err != nil || n != 1 {
+		t.Fail()
+	}
+	if buf.Len() != 6 {
+		t.Fail()
+	}
+
+	// the shared tail node must not be appended to by either buffer
+	buf.WriteUInt16(3)
+	dup.WriteUInt16(4)
+	if n, _ := buf.GetUInt16(6); n != 3 {
+		t.Fail()
+	}
+	if n, _ := dup.GetUInt16(2); n != 4 {
+		t.Fail()
+	}
+
+	refs := buf.nodeAt(1).refs
+	dup.Release()
+	if atomic.LoadInt32(refs) != 1 {
+		t.Fail()
+	}
+	if n, err := buf.ReadUInt64(); err != nil || n != 1<<32|2<<16|3 {
+		t.Fail()
+	}
+}
+
+func TestBuffer_Slice(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 4,
+	})
+	for _, c := range []byte("0123456789") {
+		buf.WriteByte(c)
+	}
+
+	s, err := buf.Slice(3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := buf.Slice(8, 3); err != ErrNoEnoughData {
+		t.Fail()
+	}
+
+	buf.Skip(10)
+	buf.WriteString("xx")
+	if data, err := s.ReadString(5); err != nil || data != "34567" {
+		t.Fail()
+	}
+
+	if s, err := buf.Slice(2, 0); err != nil || s.Len() != 0 {
+		t.Fail()
+	}
+}
+
+func TestBuffer_SliceFanOut(t *testing.T) {
+	msg := New()
+	msg.WriteString("broadcast")
+
+	var copies []*Buffer
+	for i := 0; i < 100; i++ {
+		copies = append(copies, msg.Duplicate())
+	}
+	refs := msg.nodeAt(0).refs
+	msg.Release()
+
+	for i, c := range copies {
+		if s, _ := c.ReadString(9); s != "broadcast" {
+			t.Fatal(i)
+		}
+		c.Release()
+	}
+	if atomic.LoadInt32(refs) != 0 {
+		t.Fail()
+	}
+}
+
+func TestBuffer_InsertShares(t *testing.T) {
+	buf := New()
+	buf.WriteString("abcdef")
+
+	buf.Insert(3, []byte("-"))
+	if buf.nc != 3 || buf.nodeAt(0).buf == nil || &buf.nodeAt(0).buf[0] != &buf.nodeAt(2).buf[0] {
+		t.Fatal()
+	}
+	if s, _ := buf.ReadString(7); s != "abc-def" {
+		t.Fail()
+	}
+}
+
+func TestBuffer_Split(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 4,
+	})
+	for _, c := range []byte("0123456789") {
+		buf.WriteByte(c)
+	}
+	buf.Skip(1)
+
+	head, err := buf.Split(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.Len() != 5 || head.nc != 2 || buf.Len() != 4 || buf.nc != 2 {
+		t.Fatal(head.nc, buf.nc)
+	}
+	if _, err := buf.Split(5); err != ErrNoEnoughData {
+		t.Fail()
+	}
+
+	if s, _ := head.ReadString(5); s != "12345" {
+		t.Fail()
+	}
+	if s, _ := buf.ReadString(4); s != "6789" {
+		t.Fail()
+	}
+}
+
+func TestBuffer_SplitMarked(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 4,
+	})
+	buf.WriteUInt64(0x0102030405060708)
+
+	buf.Mark()
+	head, _ := buf.Split(6)
+	if n, err := head.ReadUInt32(); err != nil || n != 0x01020304 {
+		t.Fail()
+	}
+
+	buf.ResetToMark()
+	if n, err := buf.ReadUInt64(); err != nil || n != 0x0102030405060708 {
+		t.Fail()
+	}
+}
+
+func TestBuffer_AppendBuffer(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 4,
+	})
+	buf.WriteString("ab")
+
+	other := NewWithOptions(Options{
+		MinAllocSize: 4,
+	})
+	for _, c := range []byte("xcdefgh") {
+		other.WriteByte(c)
+	}
+	other.Skip(1)
+
+	if err := buf.AppendBuffer(other); err != nil {
+		t.Fail()
+	}
+	if other.Len() != 0 || other.nc != 0 || buf.Len() != 8 {
+		t.Fatal()
+	}
+
+	buf.WriteByte('i')
+	other.WriteByte('z')
+	if s, _ := buf.ReadString(9); s != "abcdefghi" {
+		t.Fail()
+	}
+	if s, _ := other.ReadString(1); s != "z" {
+		t.Fail()
+	}
+
+	limited := NewWithOptions(Options{
+		MinAllocSize: 4,
+		MaxSize:      2,
+	})
+	other.WriteString("abc")
+	if err := limited.AppendBuffer(other); err != ErrExceedMaximumSize || other.Len() != 3 {
+		t.Fail()
+	}
+}
+
+func TestBuffer_PrependShared(t *testing.T) {
+	buf := New()
+	buf.WriteString("hello")
+	dup := buf.Duplicate()
+
+	if err := buf.Prepend(nil); err != nil || buf.Len() != 5 {
+		t.Fail()
+	}
+	if err := buf.Prepend([]byte("> ")); err != nil {
+		t.Fail()
+	}
+	if s, _ := buf.ReadString(buf.Len()); s != "> hello" {
+		t.Fail()
+	}
+	if s, _ := dup.ReadString(dup.Len()); s != "hello" {
+		t.Fail()
+	}
+}
+
+func TestBuffer_AppendBufferEmptyWriter(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 1,
+	})
+	buf.WriteByte(0x80)
+	p, _ := buf.Reserve(1)
+	p[0] = 0x05
+	buf.Commit(0)
+
+	other := New()
+	other.WriteByte(0x01)
+	buf.AppendBuffer(other)
+	if buf.nc != 2 {
+		t.Fail()
+	}
+	if x, err := buf.ReadUvarint(); err != nil || x != 128 || buf.Len() != 0 {
+		t.Fatal(x, err)
+	}
+}
+
+func TestBuffer_UvarintEmptyNode(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 1,
+	})
+	buf.WriteByte(0x80)
+	p, _ := buf.Reserve(1)
+	p[0] = 0x05
+	buf.Commit(0)
+	p, _ = buf.Reserve(2)
+	p[0] = 0x01
+	buf.Commit(1)
+
+	if buf.nc != 3 {
+		t.Fail()
+	}
+	if x, err := buf.ReadUvarint(); err != nil || x != 128 || buf.Len() != 0 {
+		t.Fatal(x, err)
+	}
+}
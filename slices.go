@@ -0,0 +1,83 @@
+package buffer
+
+// Slices holds node memory detached from a Buffer by ReadSlices. The slices returned
+// by Bytes stay valid until Release is called, regardless of what happens to the
+// Buffer they came from.
+type Slices struct {
+	nodes []*node
+	bufs  [][]byte
+	size  int
+}
+
+func (t *Slices) Bytes() [][]byte {
+	return t.bufs
+}
+
+func (t *Slices) Len() int {
+	return t.size
+}
+
+func (t *Slices) Release() {
+	for _, n := range t.nodes {
+		n.Release()
+	}
+	t.nodes = nil
+	t.bufs = nil
+	t.size = 0
+}
+
+func (t *Slices) add(n *node) {
+	t.nodes = append(t.nodes, n)
+	t.bufs = append(t.bufs, n.buf[n.r:n.w])
+	t.size += n.ReadableBytes()
+}
+
+// PeekSlices returns the readable bytes in [idx, idx+size) as sub-slices of the node
+// memory, without copying. The slices are only valid until the next call which
+// consumes or releases data, use ReadSlices to keep them longer.
+func (t *Buffer) PeekSlices(idx int, size int) ([][]byte, error) {
+	if err := t.ensureReadable(idx); err != nil {
+		return nil, err
+	}
+	if err := t.ensureReadable(idx + size); err != nil {
+		return nil, err
+	}
+	if size == 0 {
+		return nil, nil
+	}
+
+	var res [][]byte
+	i, ni := t.getNode(idx)
+	for size > 0 {
+		no := t.nodeAt(i)
+		end := no.w
+		if end-ni > size {
+			end = ni + size
+		}
+		res = append(res, no.buf[ni:end])
+		size -= end - ni
+
+		i++
+		if i < t.nc {
+			ni = t.nodeAt(i).r
+		}
+	}
+
+	return res, nil
+}
+
+// ReadSlices consumes size bytes and hands their nodes over to the returned Slices.
+// Nodes which are read completely are detached, the memory of a node that is read
+// partially is shared, so nothing is copied.
+func (t *Buffer) ReadSlices(size int) (*Slices, error) {
+	if err := t.ensureReadable(size); err != nil {
+		return nil, err
+	}
+
+	res := &Slices{}
+	for _, no := range t.take(size) {
+		res.add(no)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,76 @@
+package buffer
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBuffer_PeekSlices(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 4,
+	})
+
+	for i := 0; i < 10; i++ {
+		buf.WriteByte(byte(i))
+	}
+
+	slices, err := buf.PeekSlices(2, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(slices) != 3 {
+		t.Fatal(len(slices))
+	}
+	if !bytes.Equal(bytes.Join(slices, nil), []byte{2, 3, 4, 5, 6, 7, 8}) {
+		t.Fail()
+	}
+	if buf.Len() != 10 {
+		t.Fail()
+	}
+
+	if _, err := buf.PeekSlices(5, 6); err != ErrNoEnoughData {
+		t.Fail()
+	}
+	if slices, err := buf.PeekSlices(10, 0); err != nil || len(slices) != 0 {
+		t.Fail()
+	}
+}
+
+func TestBuffer_ReadSlices(t *testing.T) {
+	buf := NewWithOptions(Options{
+		MinAllocSize: 4,
+	})
+
+	for i := 0; i < 10; i++ {
+		buf.WriteByte(byte(i))
+	}
+
+	slices, err := buf.ReadSlices(6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slices.Len() != 6 || buf.Len() != 4 {
+		t.Fail()
+	}
+
+	// drain and refill the buffer, the detached slices must not be affected
+	buf.Skip(4)
+	for i := 0; i < 10; i++ {
+		buf.WriteByte(0xff)
+	}
+
+	if !bytes.Equal(bytes.Join(slices.Bytes(), nil), []byte{0, 1, 2, 3, 4, 5}) {
+		t.Fail()
+	}
+	slices.Release()
+	if slices.Len() != 0 || slices.Bytes() != nil {
+		t.Fail()
+	}
+
+	if n, err := buf.GetByte(9); err != nil || n != 0xff {
+		t.Fail()
+	}
+	if _, err := buf.ReadSlices(11); err != ErrNoEnoughData {
+		t.Fail()
+	}
+}
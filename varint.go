@@ -0,0 +1,95 @@
+package buffer
+
+import "encoding/binary"
+
+//#region read logic
+
+func (t *accessor) GetUvarint(idx int) (uint64, int, error) {
+	if err := t.ensureReadable(idx + 1); err != nil {
+		return 0, 0, err
+	}
+
+	return t.getUvarint(idx)
+}
+
+func (t *accessor) GetVarint(idx int) (int64, int, error) {
+	ux, n, err := t.GetUvarint(idx)
+	return zigzagDecode(ux), n, err
+}
+
+func (t *accessor) ReadUvarint() (uint64, error) {
+	x, n, err := t.GetUvarint(0)
+	if err == nil {
+		t.skip(n)
+	}
+	return x, err
+}
+
+func (t *accessor) ReadVarint() (int64, error) {
+	ux, err := t.ReadUvarint()
+	return zigzagDecode(ux), err
+}
+
+//#endregion
+
+//#region write logic
+
+func (t *accessor) WriteUvarint(n uint64) error {
+	var b [binary.MaxVarintLen64]byte
+	l := binary.PutUvarint(b[:], n)
+
+	return t.WriteBytes(b[:l])
+}
+
+func (t *accessor) WriteVarint(n int64) error {
+	return t.WriteUvarint(zigzagEncode(n))
+}
+
+//#endregion
+
+// getUvarint decodes an unsigned varint at idx like binary.Uvarint, from at most
+// binary.MaxVarintLen64 bytes peeked in one go.
+func (t *accessor) getUvarint(idx int) (uint64, int, error) {
+	var b [binary.MaxVarintLen64]byte
+	l := t.Len() - idx
+	if l > len(b) {
+		l = len(b)
+	}
+
+	x, n := binary.Uvarint(t.peek(idx, b[:l]))
+	switch {
+	case n > 0:
+		return x, n, nil
+	case n < 0 || l == len(b):
+		return 0, 0, ErrVarintOverflow
+	default:
+		return 0, 0, ErrNoEnoughData
+	}
+}
+
+// putUvarintFixed encodes x as a varint padded with continuation bytes to fill b,
+// which decodes like a regular one. It reports false when x needs more bytes.
+func putUvarintFixed(b []byte, x uint64) bool {
+	if len(b) < binary.MaxVarintLen64 && x>>(7*uint(len(b))) != 0 {
+		return false
+	}
+
+	for i := range b {
+		b[i] = byte(x) | 0x80
+		x >>= 7
+	}
+	b[len(b)-1] &= 0x7f
+	return true
+}
+
+func zigzagEncode(n int64) uint64 {
+	return uint64(n<<1) ^ uint64(n>>63)
+}
+
+func zigzagDecode(ux uint64) int64 {
+	x := int64(ux >> 1)
+	if ux&1 != 0 {
+		x = ^x
+	}
+	return x
+}
@@ -0,0 +1,118 @@
+package buffer
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func TestBuffer_WriteUvarint(t *testing.T) {
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{
+			MinAllocSize: 3,
+		})
+
+		nums := []uint64{0, 1, 127, 128, 300, 1 << 35, math.MaxUint64}
+		for _, n := range nums {
+			if err := buf.WriteUvarint(n); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		for _, n := range nums {
+			if x, err := buf.ReadUvarint(); err != nil || x != n {
+				t.Fatal(x, err)
+			}
+		}
+
+		if _, err := buf.ReadUvarint(); err != ErrNoEnoughData {
+			t.Fail()
+		}
+	})
+}
+
+func TestBuffer_WriteVarint(t *testing.T) {
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{})
+
+		nums := []int64{0, -1, 1, -64, 64, math.MinInt64, math.MaxInt64}
+		for _, n := range nums {
+			if err := buf.WriteVarint(n); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		for _, n := range nums {
+			if x, err := buf.ReadVarint(); err != nil || x != n {
+				t.Fatal(x, err)
+			}
+		}
+	})
+}
+
+func TestBuffer_GetUvarintAcrossNodes(t *testing.T) {
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{
+			MinAllocSize: 2,
+		})
+
+		var b [binary.MaxVarintLen64]byte
+		l := binary.PutUvarint(b[:], 1<<40+5)
+		for _, c := range b[:l] {
+			buf.WriteByte(c)
+		}
+
+		if b, ok := buf.ByteBuffer.(*Buffer); ok && b.nc < 2 {
+			t.Fatal()
+		}
+
+		if x, n, err := buf.GetUvarint(0); err != nil || x != 1<<40+5 || n != l {
+			t.Fail()
+		}
+
+		l = binary.PutVarint(b[:], -12345)
+		buf.WriteBytes(b[:l])
+		if x, _, err := buf.GetVarint(6); err != nil || x != -12345 {
+			t.Fail()
+		}
+	})
+}
+
+func TestBuffer_ReadUvarintIncomplete(t *testing.T) {
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{})
+
+		buf.WriteBytes([]byte{0x80, 0x80})
+		if _, err := buf.ReadUvarint(); err != ErrNoEnoughData {
+			t.Fail()
+		}
+		if buf.Len() != 2 {
+			t.Fail()
+		}
+
+		buf.WriteByte(0x01)
+		if x, err := buf.ReadUvarint(); err != nil || x != 1<<14 {
+			t.Fail()
+		}
+	})
+}
+
+func TestBuffer_ReadUvarintOverflow(t *testing.T) {
+	runBuffers(t, func(t *testing.T, newBuf func(Options) testBuffer) {
+		buf := newBuf(Options{})
+
+		buf.WriteBytes([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x02})
+		if _, err := buf.ReadUvarint(); err != ErrVarintOverflow {
+			t.Fail()
+		}
+
+		buf.Release()
+		buf.WriteBytes([]byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x01})
+		if _, err := buf.ReadUvarint(); err != ErrVarintOverflow {
+			t.Fail()
+		}
+		if buf.Len() != 11 {
+			t.Fail()
+		}
+	})
+}